@@ -1,8 +1,19 @@
 package geecaches
 
+import (
+	"encoding/binary"
+	"fmt"
+	"geecache-s/cachePolicy"
+	"time"
+)
+
 // A ByteView holds an immutable view of bytes.
 type ByteView struct {
 	Bytes []byte
+
+	expire  time.Time
+	version int64
+	noCache bool
 }
 
 // Size returns the view's length
@@ -10,6 +21,25 @@ func (bv ByteView) Size() int64 {
 	return int64(len(bv.Bytes))
 }
 
+// Expire returns when the view expires, the zero time if it never does.
+func (bv ByteView) Expire() time.Time {
+	return bv.expire
+}
+
+func (bv ByteView) expired(now time.Time) bool {
+	return !bv.expire.IsZero() && !now.Before(bv.expire)
+}
+
+// expiredNow is like expired at the current time, without reading the clock for views which never expire.
+func (bv ByteView) expiredNow() bool {
+	return !bv.expire.IsZero() && !time.Now().Before(bv.expire)
+}
+
+// Version returns the version reported by a MetaGetter, 0 if there is none.
+func (bv ByteView) Version() int64 {
+	return bv.version
+}
+
 // String returns the data as a string, making a copy if necessary.
 func (bv ByteView) String() string {
 	return string(bv.Bytes)
@@ -25,3 +55,35 @@ func cloneBytes(b []byte) []byte {
 	copy(c, b)
 	return c
 }
+
+// byteViewCodec stores ByteViews for cachePolicy.RingCache:
+// expire (8) | version (8) | bytes.
+type byteViewCodec struct{}
+
+func (byteViewCodec) AppendValue(dst []byte, value cachePolicy.Value) ([]byte, error) {
+	bv, ok := value.(ByteView)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode a value of type %T", value)
+	}
+	var expire int64
+	if !bv.expire.IsZero() {
+		expire = bv.expire.UnixNano()
+	}
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(expire))
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(bv.version))
+	return append(dst, bv.Bytes...), nil
+}
+
+func (byteViewCodec) DecodeValue(data []byte) (cachePolicy.Value, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("byte view too short: %d bytes", len(data))
+	}
+	bv := ByteView{
+		Bytes:   cloneBytes(data[16:]),
+		version: int64(binary.LittleEndian.Uint64(data[8:])),
+	}
+	if expire := int64(binary.LittleEndian.Uint64(data)); expire != 0 {
+		bv.expire = time.Unix(0, expire)
+	}
+	return bv, nil
+}
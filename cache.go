@@ -1,20 +1,232 @@
 package geecaches
 
 import (
+	"fmt"
 	"geecache-s/cachePolicy"
+	"hash/maphash"
+	"math"
 	"sync"
+	"time"
 )
 
-type cache struct {
-	mut      sync.Mutex
-	cache    cachePolicy.Cache
+// How many bytes a shard evicts at most each time it takes its lock to shrink.
+const shrinkStep = 1 << 20
+
+type cacheOptions struct {
 	policy   cachePolicy.CachePolicy
+	factory  cachePolicy.Factory // overrides policy if not nil
 	maxBytes int64
+	shards   int
+	// see GroupOptions.DecayPeriod
+	decayPeriod time.Duration
+	// see GroupOptions.BufferedReads
+	bufferedReads bool
+	// see GroupOptions.OnEvicted
+	onEvicted func(key string, value ByteView, reason cachePolicy.EvictReason)
+	// see GroupOptions.EntryOverhead
+	entryOverhead int64
+	// see GroupOptions.MaxPinnedRatio
+	pinnedRatio float64
+}
+
+// cache splits its (k, v) pairs among independently locked shards,
+// so that concurrent accesses to different keys rarely wait for each other.
+// A key always belongs to the same shard, and maxBytes is divided evenly among them.
+type cache struct {
+	opts   cacheOptions
+	seed   maphash.Seed
+	shards []*cacheShard
+}
+
+func newCache(opts cacheOptions) *cache {
+	n := max(opts.shards, 1)
+	if opts.maxBytes != 0 && int64(n) > opts.maxBytes {
+		n = int(opts.maxBytes)
+	}
+
+	c := &cache{
+		opts:   opts,
+		seed:   maphash.MakeSeed(),
+		shards: make([]*cacheShard, n),
+	}
+	for i := range c.shards {
+		shard := &cacheShard{
+			opts:     &c.opts,
+			maxBytes: opts.maxBytes / int64(n),
+		}
+		if opts.bufferedReads {
+			shard.reads = newReadBuffer(shard.drainReads)
+		}
+		c.shards[i] = shard
+	}
+	// the remainder goes to the first shard.
+	c.shards[0].maxBytes += opts.maxBytes % int64(n)
+	return c
+}
+
+// setMaxBytes changes the limit of the cache, dividing it among the shards like newCache.
+// When there are fewer bytes than shards, the shards left over hold nothing at all,
+// so that the limits of the shards still add up to maxBytes.
+// The shards which hold too many bytes for their new limit are shrunk in the background.
+func (c *cache) setMaxBytes(maxBytes int64) {
+	n := int64(len(c.shards))
+	if maxBytes != 0 && n > maxBytes {
+		n = maxBytes
+	}
+	var shrinking []*cacheShard
+	for i, s := range c.shards {
+		shardBytes := maxBytes / n
+		if i == 0 {
+			shardBytes += maxBytes % n
+		}
+		if int64(i) >= n {
+			shardBytes = noBytes
+		}
+		if s.setMaxBytes(shardBytes) {
+			shrinking = append(shrinking, s)
+		}
+	}
+
+	if len(shrinking) > 0 {
+		go func() {
+			for _, s := range shrinking {
+				s.shrink()
+			}
+		}()
+	}
+}
+
+func (c *cache) shard(key string) *cacheShard {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[maphash.String(c.seed, key)%uint64(len(c.shards))]
 }
 
 func (c *cache) get(key string) (value ByteView, ok bool) {
+	return c.shard(key).get(key)
+}
+
+// add stores a pair which took %loadCost to load, 0 if it is unknown.
+func (c *cache) add(key string, value ByteView, expire time.Time, loadCost time.Duration) error {
+	return c.shard(key).add(key, value, expire, loadCost)
+}
+
+func (c *cache) remove(key string) bool {
+	return c.shard(key).remove(key)
+}
+
+func (c *cache) pin(key string, value ByteView) error {
+	return c.shard(key).pin(key, value)
+}
+
+func (c *cache) unpin(key string) (bool, error) {
+	return c.shard(key).unpin(key)
+}
+
+func (c *cache) removeExpired() int {
+	removed := 0
+	for _, s := range c.shards {
+		removed += s.removeExpired()
+	}
+	return removed
+}
+
+// evictBytes evicts about %n bytes, taken from each shard in proportion to its size,
+// and returns how many bytes were evicted.
+func (c *cache) evictBytes(n int64) int64 {
+	sizes := make([]int64, len(c.shards))
+	var total int64
+	for i, s := range c.shards {
+		sizes[i] = s.size()
+		total += sizes[i]
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var evicted int64
+	for i, s := range c.shards {
+		if share := int64(float64(n) * float64(sizes[i]) / float64(total)); share > 0 {
+			evicted += s.evictBytes(share)
+		}
+	}
+	return evicted
+}
+
+// demandEvictions is the number of evictions made to make room for new pairs,
+// leaving out those made by setMaxBytes and evictBytes.
+func (c *cache) demandEvictions() int64 {
+	var n int64
+	for _, s := range c.shards {
+		n += s.demandEvictions()
+	}
+	return n
+}
+
+func (c *cache) stats() CacheStats {
+	var stats CacheStats
+	for _, s := range c.shards {
+		shardStats := s.stats()
+		stats.Items += shardStats.Items
+		stats.Bytes += shardStats.Bytes
+		stats.Gets += shardStats.Gets
+		stats.Hits += shardStats.Hits
+		stats.Evictions += shardStats.Evictions
+		stats.PinnedItems += shardStats.PinnedItems
+		stats.PinnedBytes += shardStats.PinnedBytes
+	}
+	return stats
+}
+
+// noBytes is the limit of a shard which holds nothing.
+const noBytes = -1
+
+// cacheShard is one of the shards of a cache, with its own lock and its own cachePolicy.Cache.
+//
+// Pinned pairs are kept aside in a map rather than in the policy, so that it cannot evict them,
+// and the policy gets what is left of maxBytes.
+//
+// With buffered reads, the pairs are also kept in a concurrent map, which is
+// only written with the lock held, so that hits are served without the lock.
+// The hits are recorded in a readBuffer and replayed into the policy later.
+type cacheShard struct {
+	mut      sync.Mutex
+	cache    cachePolicy.Cache
+	opts     *cacheOptions
+	maxBytes int64 // noBytes if the shard holds nothing, see cache.setMaxBytes
+
+	items sync.Map // string -> ByteView, mirrors cache and pinned if reads is not nil
+	reads *readBuffer
+
+	pinned      map[string]ByteView // guarded by mut
+	pinnedBytes int64               // guarded by mut
+
+	counts getCounter
+	nevict int64 // guarded by mut
+
+	// The evictions made to shrink the shard or to free memory, rather than
+	// to make room for a new pair, are also counted in nshrunk. Both guarded by mut.
+	shrinking bool
+	nshrunk   int64
+}
+
+func (c *cacheShard) get(key string) (value ByteView, ok bool) {
+	if c.reads != nil {
+		value, ok = c.getBuffered(key)
+	} else {
+		value, ok = c.getLocked(key)
+	}
+	c.counts.add(ok)
+	return value, ok
+}
+
+func (c *cacheShard) getLocked(key string) (value ByteView, ok bool) {
 	c.mut.Lock()
 	defer c.mut.Unlock()
+	if value, ok := c.pinned[key]; ok {
+		return value, true
+	}
 	if c.cache == nil {
 		return
 	}
@@ -26,17 +238,350 @@ func (c *cache) get(key string) (value ByteView, ok bool) {
 	return
 }
 
-func (c *cache) add(key string, value cachePolicy.Value) error {
+func (c *cacheShard) getBuffered(key string) (value ByteView, ok bool) {
+	v, ok := c.items.Load(key)
+	if !ok {
+		return
+	}
+
+	// an expired pair is dropped by the policy when the access is replayed.
+	c.reads.push(key)
+	value = v.(ByteView)
+	if value.expiredNow() {
+		return ByteView{}, false
+	}
+	return value, true
+}
+
+// drainReads replays a batch of buffered hits into the policy.
+// The batch is dropped if the shard is busy, rather than waiting for it.
+func (c *cacheShard) drainReads(keys []string) {
+	if !c.mut.TryLock() {
+		return
+	}
+	defer c.mut.Unlock()
+	if c.cache == nil {
+		return
+	}
+
+	for _, key := range keys {
+		if _, ok := c.pinned[key]; ok {
+			continue
+		}
+		if _, ok := c.cache.Get(key); !ok {
+			// removed by the policy, e.g. expired.
+			c.items.Delete(key)
+		}
+	}
+}
+
+func (c *cacheShard) add(key string, value ByteView, expire time.Time, loadCost time.Duration) error {
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
+	if _, ok := c.pinned[key]; ok {
+		return c.pinLocked(key, value)
+	}
+	if c.maxBytes == noBytes {
+		return fmt.Errorf("the cache has fewer bytes than shards, none is left for the shard of %q", key)
+	}
 	if c.cache == nil {
-		c.cache = cachePolicy.CreateCache(c.maxBytes, cachePolicy.CacheCallBack{}, c.policy)
+		cache, err := c.createCache()
+		if err != nil {
+			return err
+		}
+		c.cache = cache
 	}
 
-	if err := c.cache.Add(key, value); err != nil {
+	if c.reads == nil {
+		return c.addToPolicy(key, value, expire, loadCost)
+	}
+
+	// stored first, so that the policy may evict it right away.
+	old, loaded := c.items.Swap(key, value)
+	if err := c.addToPolicy(key, value, expire, loadCost); err != nil {
+		if loaded {
+			c.items.Store(key, old)
+		} else {
+			c.items.Delete(key)
+		}
 		return err
 	}
 
 	return nil
 }
+
+// addToPolicy adds a pair to the policy, along with its load cost if the policy weighs it.
+func (c *cacheShard) addToPolicy(key string, value ByteView, expire time.Time, loadCost time.Duration) error {
+	if cache, ok := c.cache.(cachePolicy.CostAwareCache); ok && loadCost > 0 {
+		return cache.AddWithLoadCost(key, value, expire, loadCost)
+	}
+	return c.cache.AddWithExpire(key, value, expire)
+}
+
+func (c *cacheShard) remove(key string) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if value, ok := c.pinned[key]; ok {
+		c.dropPinned(key)
+		c.evicted(key, value, cachePolicy.EvictRemoved)
+		return true
+	}
+	if c.cache == nil {
+		return false
+	}
+
+	c.items.Delete(key)
+	return c.cache.Remove(key)
+}
+
+// pin moves the pair of %key out of the policy, or adds it, with no expiration.
+// Pinned pairs may not take more than pinnedRatio of maxBytes.
+func (c *cacheShard) pin(key string, value ByteView) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.pinLocked(key, value)
+}
+
+func (c *cacheShard) pinLocked(key string, value ByteView) error {
+	size := c.pairSize(key, value)
+	old, replaced := c.pinned[key]
+	if replaced {
+		size -= c.pairSize(key, old)
+	}
+	if limit := int64(float64(c.maxBytes) * c.opts.pinnedRatio); c.maxBytes != 0 && c.pinnedBytes+size > limit {
+		return fmt.Errorf("pinned entries would take %d bytes, but at most %d are allowed", c.pinnedBytes+size, limit)
+	}
+
+	if c.cache != nil {
+		c.cache.Remove(key)
+	}
+	if c.pinned == nil {
+		c.pinned = make(map[string]ByteView)
+	}
+	value.expire = time.Time{}
+	c.pinned[key] = value
+	c.pinnedBytes += size
+	if c.reads != nil {
+		c.items.Store(key, value)
+	}
+	if c.cache != nil {
+		c.cache.Resize(c.policyMaxBytes())
+	}
+	if replaced {
+		c.evicted(key, old, cachePolicy.EvictReplaced)
+	}
+	return nil
+}
+
+// unpin gives the pinned pair of %key back to the policy, which may evict it from then on.
+// It returns false if %key is not pinned. If the policy cannot take the pair,
+// e.g. it is larger than what is left of maxBytes, it stays pinned and the error is returned.
+func (c *cacheShard) unpin(key string) (bool, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	value, ok := c.pinned[key]
+	if !ok {
+		return false, nil
+	}
+	if c.cache == nil {
+		cache, err := c.createCache()
+		if err != nil {
+			return false, err
+		}
+		c.cache = cache
+	}
+
+	c.dropPinned(key)
+	c.cache.Resize(c.policyMaxBytes())
+	if c.reads != nil {
+		c.items.Store(key, value)
+	}
+	if err := c.cache.Add(key, value); err != nil {
+		// nothing was added, thus shrinking the policy back evicts nothing.
+		c.pinned[key] = value
+		c.pinnedBytes += c.pairSize(key, value)
+		c.cache.Resize(c.policyMaxBytes())
+		return false, err
+	}
+	return true, nil
+}
+
+// dropPinned forgets the pinned pair of %key, without reporting it.
+func (c *cacheShard) dropPinned(key string) {
+	c.pinnedBytes -= c.pairSize(key, c.pinned[key])
+	delete(c.pinned, key)
+	c.items.Delete(key)
+}
+
+// evicted reports a pair which left the shard outside of the policy, e.g. a pinned one.
+func (c *cacheShard) evicted(key string, value ByteView, reason cachePolicy.EvictReason) {
+	if c.opts.onEvicted != nil {
+		c.opts.onEvicted(key, value, reason)
+	}
+}
+
+func (c *cacheShard) pairSize(key string, value ByteView) int64 {
+	return int64(len(key)) + value.Size() + c.opts.entryOverhead
+}
+
+// policyMaxBytes is the limit of the policy, which holds all but the pinned pairs.
+// Must be called with mut held.
+func (c *cacheShard) policyMaxBytes() int64 {
+	if c.maxBytes == 0 {
+		return 0
+	}
+	// 0 would mean no limit at all, thus a shard holding nothing gets the smallest one.
+	return max(c.maxBytes-c.pinnedBytes, 1)
+}
+
+func (c *cacheShard) removeExpired() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.cache == nil {
+		return 0
+	}
+
+	if c.reads != nil {
+		now := time.Now()
+		c.items.Range(func(key, value any) bool {
+			if value.(ByteView).expired(now) {
+				c.items.Delete(key)
+			}
+			return true
+		})
+	}
+	return c.cache.RemoveExpired()
+}
+
+// setMaxBytes changes the limit of the shard. It returns true if the shard holds
+// more bytes than that, in which case it is left to shrink to evict them.
+func (c *cacheShard) setMaxBytes(maxBytes int64) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.maxBytes = maxBytes
+	if c.cache == nil {
+		return false
+	}
+	if maxBytes == noBytes {
+		// add takes nothing in from now on.
+		return c.cache.Len() > 0
+	}
+	if limit := c.policyMaxBytes(); limit == 0 || c.cache.Size() <= limit {
+		c.cache.Resize(limit)
+		return false
+	}
+	return true
+}
+
+// shrink evicts pairs until the shard fits in its limit, shrinkStep bytes at a time,
+// so that other goroutines can use the shard in between.
+func (c *cacheShard) shrink() {
+	for {
+		c.mut.Lock()
+		if c.maxBytes == noBytes {
+			c.mut.Unlock()
+			c.evictBytes(math.MaxInt64)
+			return
+		}
+		limit := c.policyMaxBytes()
+		target := limit
+		if target != 0 && c.cache.Size()-shrinkStep > target {
+			target = c.cache.Size() - shrinkStep
+		}
+		c.shrinking = true
+		c.cache.Resize(target)
+		c.shrinking = false
+		done := target == limit
+		c.mut.Unlock()
+		if done {
+			return
+		}
+	}
+}
+
+// evictBytes evicts pairs until %n bytes are freed or the shard is empty,
+// shrinkStep bytes at a time like shrink, and returns how many bytes were evicted.
+func (c *cacheShard) evictBytes(n int64) int64 {
+	var evicted int64
+	for evicted < n {
+		c.mut.Lock()
+		if c.cache == nil || c.cache.Len() == 0 {
+			c.mut.Unlock()
+			break
+		}
+		size := c.cache.Size()
+		c.shrinking = true
+		for c.cache.Len() > 0 && size-c.cache.Size() < min(n-evicted, shrinkStep) {
+			c.cache.Evict()
+		}
+		c.shrinking = false
+		evicted += size - c.cache.Size()
+		c.mut.Unlock()
+	}
+	return evicted
+}
+
+func (c *cacheShard) size() int64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.cache == nil {
+		return 0
+	}
+	return c.cache.Size()
+}
+
+// demandEvictions is the number of evictions made to make room for new pairs.
+func (c *cacheShard) demandEvictions() int64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.nevict - c.nshrunk
+}
+
+func (c *cacheShard) stats() CacheStats {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	gets, hits := c.counts.load()
+	stats := CacheStats{
+		Gets:        gets,
+		Hits:        hits,
+		Evictions:   c.nevict,
+		Items:       int64(len(c.pinned)),
+		Bytes:       c.pinnedBytes,
+		PinnedItems: int64(len(c.pinned)),
+		PinnedBytes: c.pinnedBytes,
+	}
+	if c.cache != nil {
+		stats.Items += int64(c.cache.Len())
+		stats.Bytes += c.cache.Size()
+	}
+	return stats
+}
+
+func (c *cacheShard) createCache() (cachePolicy.Cache, error) {
+	opts := cachePolicy.Options{
+		CacheCallBack: cachePolicy.CacheCallBack{
+			OnEvicted: func(key string, value cachePolicy.Value) {
+				c.nevict++
+				if c.shrinking {
+					c.nshrunk++
+				}
+				c.items.Delete(key)
+			},
+			OnEvictedWithReason: func(key string, value cachePolicy.Value, reason cachePolicy.EvictReason) {
+				if reason == cachePolicy.EvictExpired {
+					c.items.Delete(key)
+				}
+				c.evicted(key, value.(ByteView), reason)
+			},
+		},
+		MaxBytes:      c.policyMaxBytes(),
+		DecayPeriod:   c.opts.decayPeriod,
+		EntryOverhead: c.opts.entryOverhead,
+		Codec:         byteViewCodec{},
+	}
+	if c.opts.factory != nil {
+		return c.opts.factory(opts), nil
+	}
+	return cachePolicy.CreateCacheWithOpts(c.opts.policy, opts)
+}
@@ -0,0 +1,14 @@
+package cachePolicy
+
+import "geecache-s/cachePolicy/generic"
+
+// ARCCache implements the Adaptive Replacement Cache, see generic.ARC.
+type ARCCache = generic.ARC[string, Value]
+
+func init() {
+	Register(ArcPolicy, func(opts Options) Cache { return NewARCCache(opts) })
+}
+
+func NewARCCache(opts Options) *ARCCache {
+	return generic.NewARC(genericOptions(opts, pairSize))
+}
@@ -1,8 +1,12 @@
 package cachePolicy
 
 import (
-	"container/list"
 	"fmt"
+	"geecache-s/cachePolicy/generic"
+	"iter"
+	"sort"
+	"sync"
+	"time"
 )
 
 // Value use Len to count how many bytes it takes
@@ -10,12 +14,23 @@ type Value interface {
 	Size() int64
 }
 
+// The name of a cache replacement policy, see Register.
+type CachePolicy string
+
+// Built-in policies.
 const (
-	LruPolicy = iota
-	LfuPolicy
-)
+	LruPolicy CachePolicy = "lru"
+	LfuPolicy CachePolicy = "lfu"
+	ArcPolicy CachePolicy = "arc"
 
-type CachePolicy int
+	TinyLfuPolicy CachePolicy = "tinylfu"
+	SievePolicy   CachePolicy = "sieve"
+	S3FifoPolicy  CachePolicy = "s3fifo"
+	RingPolicy    CachePolicy = "ring"
+	GdsfPolicy    CachePolicy = "gdsf"
+	LruKPolicy    CachePolicy = "lruk"
+	LirsPolicy    CachePolicy = "lirs"
+)
 
 // It is not safe for concurrent access.
 type Cache interface {
@@ -28,9 +43,36 @@ type Cache interface {
 	// When length of value is larger than maxBytes, Add will return a error.
 	Add(key string, value Value) error
 
+	// Same as Add, but the pair expires at %expire, after which Get treats it as missing.
+	// A zero %expire means the pair never expires.
+	AddWithExpire(key string, value Value, expire time.Time) error
+
 	// Evict a (k, v) pair.
 	Evict()
 
+	// Remove the (k, v) pair of %key, return false if %key is not in cache.
+	Remove(key string) bool
+
+	// Remove all expired (k, v) pairs, return how many pairs were removed.
+	RemoveExpired() int
+
+	// Same as Get, but the recency and frequency of the pair are left untouched.
+	Peek(key string) (Value, bool)
+
+	// Report whether %key is in cache, without touching the pair like Peek.
+	Contains(key string) bool
+
+	// Change the maximum number of bytes available for all pairs and evict pairs until they fit,
+	// return how many pairs were evicted. A %maxBytes of 0 means there is no limit.
+	Resize(maxBytes int64) int
+
+	// Remove all (k, v) pairs.
+	Purge()
+
+	// Return an iterator over the (k, v) pairs which are not expired, roughly in the order
+	// they would be evicted. The cache must not be modified during the iteration.
+	All() iter.Seq2[string, Value]
+
 	// Return number of (k, v) pairs.
 	Len() int
 
@@ -38,34 +80,136 @@ type Cache interface {
 	Size() int64
 }
 
+// A CostAwareCache also weighs how long its pairs take to load again, e.g. GDSFCache.
+// Groups record the latency of their Getter through it.
+type CostAwareCache interface {
+	Cache
+
+	// Same as AddWithExpire, but the pair took %loadCost to load.
+	AddWithLoadCost(key string, value Value, expire time.Time, loadCost time.Duration) error
+}
+
 type CacheCallBack struct {
 	// optional and executed when an entry is purged.
 	OnEvicted func(key string, value Value)
+
+	// optional and executed whenever an entry leaves the cache or its value is replaced,
+	// along with the reason, after the cache has been updated.
+	OnEvictedWithReason func(key string, value Value, reason EvictReason)
+}
+
+// An EvictReason tells why an entry left a cache.
+type EvictReason = generic.EvictReason
+
+const (
+	EvictCapacity = generic.EvictCapacity
+	EvictExpired  = generic.EvictExpired
+	EvictRemoved  = generic.EvictRemoved
+	EvictReplaced = generic.EvictReplaced
+)
+
+// Options are passed to a Factory to create a Cache.
+type Options struct {
+	CacheCallBack
+
+	// The maximum number of bytes available for all pairs.
+	// When the value is 0, there is no limit and it's assumed
+	// that eviction is done by the caller.
+	MaxBytes int64
+
+	// How often frequency based policies halve the access counts of their entries,
+	// so that eviction reflects recent popularity. It is checked whenever the cache is accessed.
+	// When the value is 0, counts never decay.
+	DecayPeriod time.Duration
+
+	// The number of bytes counted for each pair on top of its key and value,
+	// so that MaxBytes and Size account for the memory the cache itself takes.
+	// EntryOverhead is an estimate for the built-in policies.
+	EntryOverhead int64
+
+	// How policies which store values as bytes, such as RingCache, encode them.
+	// Other policies ignore it.
+	Codec ValueCodec
+}
+
+// EntryOverhead is an estimate of the heap memory a built-in policy takes for each pair
+// besides its key and value: the entry in the slice of its pool, the slot of the index map,
+// and the Value interface boxing a ByteView. It is measured with LRU and LFU, the policies
+// keeping their entries in a linked list take about 40 bytes more, Ring about 100 bytes less.
+const EntryOverhead = 192
+
+// A Factory creates an empty Cache of some policy.
+type Factory func(opts Options) Cache
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[CachePolicy]Factory)
+)
+
+// Register makes a cache policy available by the provided name.
+// If Register is called twice with the same name or if factory is nil, it panics.
+func Register(name CachePolicy, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("cachePolicy: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("cachePolicy: Register called twice for policy " + name)
+	}
+	factories[name] = factory
 }
 
-// New creates a new Cache.
-// If maxEntries is zero, the cache has no limit and it's assumed
+// Policies returns a sorted list of the names of the registered policies.
+func Policies() []CachePolicy {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	list := make([]CachePolicy, 0, len(factories))
+	for name := range factories {
+		list = append(list, name)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// CreateCache creates a new Cache of the registered policy.
+// If maxBytes is zero, the cache has no limit and it's assumed
 // that eviction is done by the caller.
-func CreateCache(maxBytes int64, callBacks CacheCallBack, cacheType CachePolicy) Cache {
-	switch cacheType {
-	case LruPolicy:
-		return &LRUCache{
-			usedMap:       make(map[string]*list.Element),
-			usedList:      list.New(),
-			maxBytes:      maxBytes,
-			curBytes:      0,
-			CacheCallBack: callBacks,
-		}
-	case LfuPolicy:
-		return &LFUCache{
-			freqList:      list.New(),
-			freqMap:       make(map[int]*list.Element),
-			entryMap:      make(map[string]*list.Element),
-			maxBytes:      maxBytes,
-			curBytes:      0,
-			CacheCallBack: callBacks,
-		}
-	default:
-		panic(fmt.Sprintf("This cache replacement policy is not supported, which cache policy code is %d", cacheType))
+func CreateCache(maxBytes int64, callBacks CacheCallBack, policy CachePolicy) (Cache, error) {
+	return CreateCacheWithOpts(policy, Options{
+		CacheCallBack: callBacks,
+		MaxBytes:      maxBytes,
+	})
+}
+
+// CreateCacheWithOpts creates a new Cache of the registered policy with the given options.
+func CreateCacheWithOpts(policy CachePolicy, opts Options) (Cache, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[policy]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("this cache replacement policy is not supported, which cache policy is %q", policy)
 	}
+	return factory(opts), nil
+}
+
+// genericOptions converts %opts to the options of the generic cache behind a built-in policy,
+// where a pair costs the bytes it takes as given by %size, plus the overhead of an entry.
+func genericOptions(opts Options, size func(key string, value Value) int64) generic.Options[string, Value] {
+	cost := size
+	if overhead := opts.EntryOverhead; overhead != 0 {
+		cost = func(key string, value Value) int64 { return size(key, value) + overhead }
+	}
+	return generic.Options[string, Value]{
+		MaxCost:             opts.MaxBytes,
+		Cost:                cost,
+		OnEvicted:           opts.OnEvicted,
+		OnEvictedWithReason: opts.OnEvictedWithReason,
+		DecayPeriod:         opts.DecayPeriod,
+	}
+}
+
+// pairSize is the number of bytes taken by a pair.
+func pairSize(key string, value Value) int64 {
+	return int64(len(key)) + value.Size()
 }
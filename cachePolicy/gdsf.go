@@ -0,0 +1,14 @@
+package cachePolicy
+
+import "geecache-s/cachePolicy/generic"
+
+// GDSFCache implements GreedyDual-Size-Frequency, see generic.GDSF.
+type GDSFCache = generic.GDSF[string, Value]
+
+func init() {
+	Register(GdsfPolicy, func(opts Options) Cache { return NewGDSFCache(opts) })
+}
+
+func NewGDSFCache(opts Options) *GDSFCache {
+	return generic.NewGDSF(genericOptions(opts, pairSize))
+}
@@ -0,0 +1,270 @@
+package generic
+
+import (
+	"container/list"
+	"iter"
+	"math"
+	"time"
+)
+
+type arcEntry[K comparable, V any] struct {
+	key    K
+	value  V     // zero for a ghost entry
+	cost   int64 // cost of the pair when it was resident
+	expire time.Time
+	list   *costList
+}
+
+// ARC implements the Adaptive Replacement Cache.
+// T1 holds the pairs seen once recently, T2 the pairs seen at least twice,
+// B1 and B2 hold the keys recently evicted from T1 and T2 respectively (ghosts).
+// A hit on a ghost moves the target size of T1 towards the list which would have kept it.
+// Sizes are counted in cost instead of entries.
+type ARC[K comparable, V any] struct {
+	opts           Options[K, V]
+	t1, t2, b1, b2 *costList
+	entries        map[K]*list.Element
+
+	// The target cost of T1.
+	p int64
+}
+
+func NewARC[K comparable, V any](opts Options[K, V]) *ARC[K, V] {
+	return &ARC[K, V]{
+		opts:    opts,
+		t1:      newCostList(),
+		t2:      newCostList(),
+		b1:      newCostList(),
+		b2:      newCostList(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+func (arc *ARC[K, V]) Get(key K) (value V, ok bool) {
+	elem, ok := arc.entries[key]
+	if !ok || !arc.resident(elem) {
+		return value, false
+	}
+
+	entry := elem.Value.(*arcEntry[K, V])
+	if expiredNow(entry.expire) {
+		arc.remove(elem, EvictExpired)
+		return value, false
+	}
+	arc.unlink(elem)
+	arc.link(arc.t2, entry)
+	return entry.value, true
+}
+
+func (arc *ARC[K, V]) Add(key K, value V) error {
+	return arc.AddWithExpire(key, value, time.Time{})
+}
+
+func (arc *ARC[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := arc.opts.cost(key, value)
+	if err := arc.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	entry := &arcEntry[K, V]{
+		key:    key,
+		value:  value,
+		cost:   cost,
+		expire: expire,
+	}
+	elem, ok := arc.entries[key]
+	if !ok {
+		// a miss: the pair has been seen once.
+		arc.replace(cost, false)
+		arc.link(arc.t1, entry)
+		arc.trimGhosts()
+		return nil
+	}
+
+	old := elem.Value.(*arcEntry[K, V])
+	replaced := arc.resident(elem)
+	arc.unlink(elem)
+	switch old.list {
+	case arc.b1:
+		// T1 was too small to keep it.
+		arc.p = min(arc.opts.MaxCost, arc.p+arc.delta(cost, arc.b2.cost, arc.b1.cost+old.cost))
+		arc.replace(cost, false)
+	case arc.b2:
+		// T2 was too small to keep it.
+		arc.p = max(0, arc.p-arc.delta(cost, arc.b1.cost, arc.b2.cost+old.cost))
+		arc.replace(cost, true)
+	default:
+		arc.replace(cost, false)
+	}
+	arc.link(arc.t2, entry)
+	arc.trimGhosts()
+	if replaced {
+		arc.opts.evicted(key, old.value, EvictReplaced)
+	}
+	return nil
+}
+
+func (arc *ARC[K, V]) Evict() {
+	if arc.Len() == 0 {
+		return
+	}
+	arc.demote(false)
+	arc.trimGhosts()
+}
+
+func (arc *ARC[K, V]) Remove(key K) bool {
+	if elem, ok := arc.entries[key]; ok {
+		if !arc.resident(elem) {
+			arc.unlink(elem)
+			return false
+		}
+		arc.remove(elem, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (arc *ARC[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, l := range []*costList{arc.t1, arc.t2} {
+		for elem := l.Front(); elem != nil; {
+			next := elem.Next()
+			if expired(elem.Value.(*arcEntry[K, V]).expire, now) {
+				arc.remove(elem, EvictExpired)
+				removed++
+			}
+			elem = next
+		}
+	}
+	return removed
+}
+
+func (arc *ARC[K, V]) Peek(key K) (value V, ok bool) {
+	if elem, ok := arc.entries[key]; ok && arc.resident(elem) {
+		entry := elem.Value.(*arcEntry[K, V])
+		if !expiredNow(entry.expire) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (arc *ARC[K, V]) Contains(key K) bool {
+	_, ok := arc.Peek(key)
+	return ok
+}
+
+func (arc *ARC[K, V]) Resize(maxCost int64) int {
+	arc.opts.MaxCost = maxCost
+	arc.p = min(arc.p, maxCost)
+	evicted := shrink(arc, maxCost)
+	arc.trimGhosts()
+	return evicted
+}
+
+func (arc *ARC[K, V]) Purge() {
+	for _, l := range []*costList{arc.t1, arc.t2} {
+		for elem := l.Back(); elem != nil; elem = l.Back() {
+			arc.remove(elem, EvictRemoved)
+		}
+	}
+	for _, l := range []*costList{arc.b1, arc.b2} {
+		for elem := l.Back(); elem != nil; elem = l.Back() {
+			arc.unlink(elem)
+		}
+	}
+	arc.p = 0
+}
+
+func (arc *ARC[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for _, l := range []*costList{arc.t1, arc.t2} {
+			for elem := l.Back(); elem != nil; elem = elem.Prev() {
+				entry := elem.Value.(*arcEntry[K, V])
+				if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (arc *ARC[K, V]) Len() int {
+	return arc.t1.Len() + arc.t2.Len()
+}
+
+func (arc *ARC[K, V]) Size() int64 {
+	return arc.t1.cost + arc.t2.cost
+}
+
+// replace demotes resident pairs to the ghost lists until %need more cost fits in the cache.
+func (arc *ARC[K, V]) replace(need int64, inB2 bool) {
+	for arc.opts.MaxCost != 0 && arc.Len() > 0 && arc.Size()+need > arc.opts.MaxCost {
+		arc.demote(inB2)
+	}
+}
+
+// demote evicts the LRU pair of T1 or T2, depending on how T1 compares to its target size,
+// and remembers its key in the corresponding ghost list.
+func (arc *ARC[K, V]) demote(inB2 bool) {
+	from, to := arc.t2, arc.b2
+	if arc.t1.Len() > 0 && (arc.t1.cost > arc.p || (inB2 && arc.t1.cost == arc.p) || arc.t2.Len() == 0) {
+		from, to = arc.t1, arc.b1
+	}
+
+	elem := from.Back()
+	entry := elem.Value.(*arcEntry[K, V])
+	value := entry.value
+	arc.unlink(elem)
+	var zero V
+	entry.value, entry.expire = zero, time.Time{}
+	arc.link(to, entry)
+
+	arc.opts.evicted(entry.key, value, EvictCapacity)
+}
+
+// trimGhosts keeps T1+B1 within MaxCost and all four lists within twice MaxCost.
+func (arc *ARC[K, V]) trimGhosts() {
+	maxCost := arc.opts.MaxCost
+	for arc.b1.Len() > 0 && arc.t1.cost+arc.b1.cost > maxCost {
+		arc.unlink(arc.b1.Back())
+	}
+	for arc.b2.Len() > 0 && arc.Size()+arc.b1.cost+arc.b2.cost > 2*maxCost {
+		arc.unlink(arc.b2.Back())
+	}
+}
+
+// delta is how far a ghost hit of %cost moves the target size of T1:
+// the less cost in the ghost list which was hit, compared to the other one, the further.
+func (arc *ARC[K, V]) delta(cost, otherCost, hitCost int64) int64 {
+	if hitCost <= 0 || otherCost <= hitCost {
+		return cost
+	}
+	return int64(math.Min(float64(cost)*float64(otherCost)/float64(hitCost), float64(arc.opts.MaxCost)))
+}
+
+func (arc *ARC[K, V]) resident(elem *list.Element) bool {
+	l := elem.Value.(*arcEntry[K, V]).list
+	return l == arc.t1 || l == arc.t2
+}
+
+func (arc *ARC[K, V]) link(l *costList, entry *arcEntry[K, V]) {
+	entry.list = l
+	l.cost += entry.cost
+	arc.entries[entry.key] = l.PushFront(entry)
+}
+
+// remove drops a resident pair and reports it for %reason.
+func (arc *ARC[K, V]) remove(elem *list.Element, reason EvictReason) {
+	entry := elem.Value.(*arcEntry[K, V])
+	arc.unlink(elem)
+	arc.opts.evicted(entry.key, entry.value, reason)
+}
+
+func (arc *ARC[K, V]) unlink(elem *list.Element) {
+	entry := elem.Value.(*arcEntry[K, V])
+	entry.list.cost -= entry.cost
+	entry.list.Remove(elem)
+	delete(arc.entries, entry.key)
+}
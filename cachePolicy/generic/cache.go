@@ -0,0 +1,200 @@
+// Package generic implements the cache replacement policies of cachePolicy
+// for any comparable key type and any value type, so that they can be used
+// directly as in-process caches. How much a pair counts towards the capacity
+// of a cache is given by a user-supplied cost function.
+package generic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"time"
+)
+
+// It is not safe for concurrent access.
+type Cache[K comparable, V any] interface {
+	// Retrn the value corresponding to the key
+	Get(key K) (V, bool)
+
+	// If %key is not exists, insert a (k, v) pair.
+	// If %key is in cache, update old value to incoming value.
+	// If cache if full, evicted one of (k, v) pairs in cache.
+	// When the cost of the pair is larger than MaxCost, Add will return a error.
+	Add(key K, value V) error
+
+	// Same as Add, but the pair expires at %expire, after which Get treats it as missing.
+	// A zero %expire means the pair never expires.
+	AddWithExpire(key K, value V, expire time.Time) error
+
+	// Evict a (k, v) pair.
+	Evict()
+
+	// Remove the (k, v) pair of %key, return false if %key is not in cache.
+	Remove(key K) bool
+
+	// Remove all expired (k, v) pairs, return how many pairs were removed.
+	RemoveExpired() int
+
+	// Same as Get, but the recency and frequency of the pair are left untouched.
+	Peek(key K) (V, bool)
+
+	// Report whether %key is in cache, without touching the pair like Peek.
+	Contains(key K) bool
+
+	// Change the maximum total cost of the pairs and evict pairs until they fit in it,
+	// return how many pairs were evicted. A %maxCost of 0 means there is no limit.
+	Resize(maxCost int64) int
+
+	// Remove all (k, v) pairs.
+	Purge()
+
+	// Return an iterator over the (k, v) pairs which are not expired, roughly in the order
+	// they would be evicted. The cache must not be modified during the iteration.
+	All() iter.Seq2[K, V]
+
+	// Return number of (k, v) pairs.
+	Len() int
+
+	// Return the total cost of the pairs.
+	Size() int64
+}
+
+// A CostAware cache also weighs how long its pairs take to load again, e.g. GDSF.
+type CostAware[K comparable, V any] interface {
+	Cache[K, V]
+
+	// Same as AddWithExpire, but the pair took %loadCost to load.
+	AddWithLoadCost(key K, value V, expire time.Time, loadCost time.Duration) error
+}
+
+// An EvictReason tells why a pair left a cache.
+type EvictReason uint8
+
+const (
+	// The pair was evicted to make room for others.
+	EvictCapacity EvictReason = iota
+	// The pair expired.
+	EvictExpired
+	// The pair was removed by Remove.
+	EvictRemoved
+	// The value was replaced by Add, the key is still cached with the new value.
+	EvictReplaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	case EvictRemoved:
+		return "removed"
+	case EvictReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// Options are used to create a Cache.
+type Options[K comparable, V any] struct {
+	// The maximum total cost of all pairs.
+	// When the value is 0, there is no limit and it's assumed
+	// that eviction is done by the caller.
+	MaxCost int64
+
+	// Cost returns how much a pair counts towards MaxCost, e.g. the number of bytes it takes.
+	// It must return the same cost for the same pair. When it is nil, every pair costs 1,
+	// so that MaxCost is the maximum number of pairs.
+	Cost func(key K, value V) int64
+
+	// optional and executed when an entry is purged.
+	OnEvicted func(key K, value V)
+
+	// optional and executed whenever a pair leaves the cache or its value is replaced,
+	// along with the reason, after the cache has been updated.
+	OnEvictedWithReason func(key K, value V, reason EvictReason)
+
+	// How often frequency based policies halve the access counts of their entries,
+	// so that eviction reflects recent popularity. It is checked whenever the cache is accessed.
+	// When the value is 0, counts never decay.
+	DecayPeriod time.Duration
+
+	// Hash is used by the policies which estimate access frequencies with a sketch.
+	// When it is nil, string and integer keys are hashed directly and the other keys
+	// are hashed through their fmt representation, which is slow.
+	Hash func(key K) uint64
+}
+
+func (opts *Options[K, V]) cost(key K, value V) int64 {
+	if opts.Cost == nil {
+		return 1
+	}
+	return opts.Cost(key, value)
+}
+
+func (opts *Options[K, V]) evicted(key K, value V, reason EvictReason) {
+	if reason == EvictCapacity && opts.OnEvicted != nil {
+		opts.OnEvicted(key, value)
+	}
+	if opts.OnEvictedWithReason != nil {
+		opts.OnEvictedWithReason(key, value, reason)
+	}
+}
+
+// checkCost returns an error if a pair of %cost can never fit in the cache.
+func (opts *Options[K, V]) checkCost(cost int64) error {
+	if opts.MaxCost != 0 && cost > opts.MaxCost {
+		return fmt.Errorf("the cost of the pair is too large, need less than %d which is %d", opts.MaxCost, cost)
+	}
+	return nil
+}
+
+func (opts *Options[K, V]) hasher() func(key K) uint64 {
+	if opts.Hash != nil {
+		return opts.Hash
+	}
+	seed := maphash.MakeSeed()
+	return func(key K) uint64 {
+		var n uint64
+		switch k := any(key).(type) {
+		case string:
+			return maphash.String(seed, k)
+		case int:
+			n = uint64(k)
+		case int32:
+			n = uint64(k)
+		case int64:
+			n = uint64(k)
+		case uint:
+			n = uint64(k)
+		case uint32:
+			n = uint64(k)
+		case uint64:
+			n = k
+		default:
+			return maphash.String(seed, fmt.Sprint(key))
+		}
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], n)
+		return maphash.Bytes(seed, buf[:])
+	}
+}
+
+// shrink evicts pairs from %c until they fit in %maxCost, it returns how many pairs were evicted.
+func shrink[K comparable, V any](c Cache[K, V], maxCost int64) int {
+	n := c.Len()
+	for maxCost != 0 && c.Len() > 0 && c.Size() > maxCost {
+		c.Evict()
+	}
+	return n - c.Len()
+}
+
+func expired(expire time.Time, now time.Time) bool {
+	return !expire.IsZero() && !now.Before(expire)
+}
+
+// expiredNow is like expired at the current time, without reading the clock for pairs which never expire.
+func expiredNow(expire time.Time) bool {
+	return !expire.IsZero() && !time.Now().Before(expire)
+}
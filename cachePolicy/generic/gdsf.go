@@ -0,0 +1,252 @@
+package generic
+
+import (
+	"cmp"
+	"container/heap"
+	"iter"
+	"slices"
+	"time"
+)
+
+type gdsfEntry[K comparable, V any] struct {
+	key      K
+	value    V
+	cost     int64
+	expire   time.Time
+	freq     int
+	loadCost float64 // how long the pair took to load, in nanoseconds
+	priority float64
+	index    int // in the heap
+}
+
+// GDSF implements GreedyDual-Size-Frequency, which evicts the pair of the lowest priority
+//
+//	L + frequency * load cost / cost
+//
+// first, so that small pairs which are accessed often and are slow to load again stay cached.
+// L is the priority of the last evicted pair, thus the pairs which are not accessed anymore
+// age as newer ones get higher priorities.
+// The load cost of a pair is given by AddWithLoadCost. Pairs added otherwise keep the load cost
+// they had, or get the average one of the cache.
+type GDSF[K comparable, V any] struct {
+	opts    Options[K, V]
+	entries map[K]*gdsfEntry[K, V]
+	heap    gdsfHeap[K, V]
+
+	// The inflation value L.
+	inflation float64
+
+	// The current total cost and load cost of the pairs.
+	curCost      int64
+	curLoadCosts float64
+}
+
+func NewGDSF[K comparable, V any](opts Options[K, V]) *GDSF[K, V] {
+	return &GDSF[K, V]{
+		opts:    opts,
+		entries: make(map[K]*gdsfEntry[K, V]),
+	}
+}
+
+func (c *GDSF[K, V]) Get(key K) (value V, ok bool) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return value, false
+	}
+	if expiredNow(entry.expire) {
+		c.remove(entry, EvictExpired)
+		return value, false
+	}
+	entry.freq++
+	c.prioritize(entry)
+	return entry.value, true
+}
+
+func (c *GDSF[K, V]) Add(key K, value V) error {
+	return c.AddWithExpire(key, value, time.Time{})
+}
+
+func (c *GDSF[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	loadCost := 1.0
+	if entry, ok := c.entries[key]; ok {
+		loadCost = entry.loadCost
+	} else if len(c.entries) > 0 {
+		loadCost = c.curLoadCosts / float64(len(c.entries))
+	}
+	return c.add(key, value, expire, loadCost)
+}
+
+// AddWithLoadCost is like AddWithExpire, but the pair took %loadCost to load,
+// which is how much it is worth keeping.
+func (c *GDSF[K, V]) AddWithLoadCost(key K, value V, expire time.Time, loadCost time.Duration) error {
+	return c.add(key, value, expire, float64(max(loadCost, 1)))
+}
+
+func (c *GDSF[K, V]) add(key K, value V, expire time.Time, loadCost float64) error {
+	cost := c.opts.cost(key, value)
+	if err := c.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	maxCost := c.opts.MaxCost
+	if entry, ok := c.entries[key]; ok {
+		old := entry.value
+		c.curCost += cost - entry.cost
+		c.curLoadCosts += loadCost - entry.loadCost
+		entry.value, entry.cost, entry.expire, entry.loadCost = value, cost, expire, loadCost
+		entry.freq++
+		c.prioritize(entry)
+		c.opts.evicted(key, old, EvictReplaced)
+		// the pair itself may be evicted if it still has the lowest priority.
+		for maxCost != 0 && c.curCost > maxCost {
+			c.Evict()
+		}
+		return nil
+	}
+
+	for maxCost != 0 && len(c.entries) > 0 && c.curCost+cost > maxCost {
+		c.Evict()
+	}
+	entry := &gdsfEntry[K, V]{
+		key:      key,
+		value:    value,
+		cost:     cost,
+		expire:   expire,
+		freq:     1,
+		loadCost: loadCost,
+	}
+	entry.priority = c.priority(entry)
+	c.entries[key] = entry
+	heap.Push(&c.heap, entry)
+	c.curCost += cost
+	c.curLoadCosts += loadCost
+	return nil
+}
+
+func (c *GDSF[K, V]) Evict() {
+	if len(c.heap) == 0 {
+		return
+	}
+	entry := c.heap[0]
+	c.inflation = entry.priority
+	c.remove(entry, EvictCapacity)
+}
+
+func (c *GDSF[K, V]) Remove(key K) bool {
+	if entry, ok := c.entries[key]; ok {
+		c.remove(entry, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (c *GDSF[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, entry := range c.entries {
+		if expired(entry.expire, now) {
+			c.remove(entry, EvictExpired)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *GDSF[K, V]) Peek(key K) (value V, ok bool) {
+	if entry, ok := c.entries[key]; ok && !expiredNow(entry.expire) {
+		return entry.value, true
+	}
+	return value, false
+}
+
+func (c *GDSF[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+func (c *GDSF[K, V]) Resize(maxCost int64) int {
+	c.opts.MaxCost = maxCost
+	return shrink(c, maxCost)
+}
+
+func (c *GDSF[K, V]) Purge() {
+	for len(c.heap) > 0 {
+		c.remove(c.heap[0], EvictRemoved)
+	}
+	c.inflation = 0
+}
+
+func (c *GDSF[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		sorted := slices.Clone(c.heap)
+		slices.SortFunc(sorted, func(a, b *gdsfEntry[K, V]) int {
+			return cmp.Compare(a.priority, b.priority)
+		})
+		for _, entry := range sorted {
+			if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *GDSF[K, V]) Len() int {
+	return len(c.entries)
+}
+
+func (c *GDSF[K, V]) Size() int64 {
+	return c.curCost
+}
+
+func (c *GDSF[K, V]) priority(entry *gdsfEntry[K, V]) float64 {
+	return c.inflation + float64(entry.freq)*entry.loadCost/float64(max(entry.cost, 1))
+}
+
+// prioritize updates the priority of %entry after an access.
+func (c *GDSF[K, V]) prioritize(entry *gdsfEntry[K, V]) {
+	entry.priority = c.priority(entry)
+	heap.Fix(&c.heap, entry.index)
+}
+
+// remove drops a pair and reports it for %reason.
+func (c *GDSF[K, V]) remove(entry *gdsfEntry[K, V], reason EvictReason) {
+	heap.Remove(&c.heap, entry.index)
+	delete(c.entries, entry.key)
+	c.curCost -= entry.cost
+	c.curLoadCosts -= entry.loadCost
+	if len(c.entries) == 0 {
+		// no rounding error left behind.
+		c.curLoadCosts = 0
+	}
+	c.opts.evicted(entry.key, entry.value, reason)
+}
+
+// gdsfHeap is a min-heap of the entries by priority, for container/heap.
+type gdsfHeap[K comparable, V any] []*gdsfEntry[K, V]
+
+func (h gdsfHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h gdsfHeap[K, V]) Less(i, j int) bool {
+	return h[i].priority < h[j].priority
+}
+
+func (h gdsfHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *gdsfHeap[K, V]) Push(x any) {
+	entry := x.(*gdsfEntry[K, V])
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *gdsfHeap[K, V]) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,269 @@
+package generic
+
+import (
+	"iter"
+	"time"
+)
+
+type lfuEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	cost   int64
+	expire time.Time
+	bucket int32 // the bucket of the frequency of the entry
+}
+
+// lfuBucket holds the entries of the same frequency, front is the least recently used.
+type lfuBucket struct {
+	freq    int
+	entries int32 // the sentinel of the list of entries
+}
+
+// LFU evicts the least frequently used pair first,
+// and the least recently used one among pairs of the same frequency.
+// Its entries and buckets are kept in pools, so that Add and Get do not allocate once it is full.
+type LFU[K comparable, V any] struct {
+	opts    Options[K, V]
+	entries pool[lfuEntry[K, V]]
+	buckets pool[lfuBucket]
+	freqs   int32 // the sentinel of the list of buckets, by increasing frequency
+	index   map[K]int32
+
+	// The current total cost of the pairs.
+	curCost int64
+
+	// Frequencies are halved every opts.DecayPeriod, if it is not 0.
+	lastDecay time.Time
+}
+
+func NewLFU[K comparable, V any](opts Options[K, V]) *LFU[K, V] {
+	lfu := &LFU[K, V]{
+		opts:      opts,
+		entries:   newPool[lfuEntry[K, V]](),
+		buckets:   newPool[lfuBucket](),
+		index:     make(map[K]int32),
+		lastDecay: time.Now(),
+	}
+	lfu.freqs = lfu.buckets.newList()
+	return lfu
+}
+
+func (lfu *LFU[K, V]) Get(key K) (value V, ok bool) {
+	lfu.maybeDecay()
+	if i, ok := lfu.index[key]; ok {
+		if expiredNow(lfu.entries.at(i).expire) {
+			lfu.removeEntry(i, EvictExpired)
+			return value, false
+		}
+		lfu.increaseFreq(i)
+		return lfu.entries.at(i).value, true
+	}
+	return value, false
+}
+
+func (lfu *LFU[K, V]) Add(key K, value V) error {
+	return lfu.AddWithExpire(key, value, time.Time{})
+}
+
+func (lfu *LFU[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := lfu.opts.cost(key, value)
+	if err := lfu.opts.checkCost(cost); err != nil {
+		return err
+	}
+	lfu.maybeDecay()
+
+	maxCost := lfu.opts.MaxCost
+	if i, ok := lfu.index[key]; ok {
+		entry := lfu.entries.at(i)
+		old := entry.value
+		lfu.curCost += cost - entry.cost
+		entry.value, entry.cost, entry.expire = value, cost, expire
+		lfu.increaseFreq(i)
+		lfu.opts.evicted(key, old, EvictReplaced)
+		// the pair itself may be evicted if it is still the least frequently used one.
+		for maxCost != 0 && lfu.curCost > maxCost {
+			lfu.Evict()
+		}
+		return nil
+	}
+
+	for maxCost != 0 && lfu.curCost+cost > maxCost {
+		lfu.Evict()
+	}
+	b := lfu.buckets.next(lfu.freqs)
+	if b == lfu.freqs || lfu.buckets.at(b).freq != 1 {
+		b = lfu.newBucket(1, lfu.freqs)
+	}
+	i := lfu.entries.alloc(lfuEntry[K, V]{
+		key:    key,
+		value:  value,
+		cost:   cost,
+		expire: expire,
+		bucket: b,
+	})
+	lfu.pushBack(b, i)
+	lfu.index[key] = i
+	lfu.curCost += cost
+	return nil
+}
+
+func (lfu *LFU[K, V]) Evict() {
+	b := lfu.buckets.next(lfu.freqs)
+	if b == lfu.freqs {
+		return
+	}
+	lfu.removeEntry(lfu.entries.next(lfu.buckets.at(b).entries), EvictCapacity)
+}
+
+func (lfu *LFU[K, V]) Remove(key K) bool {
+	if i, ok := lfu.index[key]; ok {
+		lfu.removeEntry(i, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (lfu *LFU[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, i := range lfu.index {
+		if expired(lfu.entries.at(i).expire, now) {
+			lfu.removeEntry(i, EvictExpired)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (lfu *LFU[K, V]) Peek(key K) (value V, ok bool) {
+	if i, ok := lfu.index[key]; ok {
+		entry := lfu.entries.at(i)
+		if !expiredNow(entry.expire) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (lfu *LFU[K, V]) Contains(key K) bool {
+	_, ok := lfu.Peek(key)
+	return ok
+}
+
+func (lfu *LFU[K, V]) Resize(maxCost int64) int {
+	lfu.opts.MaxCost = maxCost
+	return shrink(lfu, maxCost)
+}
+
+func (lfu *LFU[K, V]) Purge() {
+	for b := lfu.buckets.next(lfu.freqs); b != lfu.freqs; b = lfu.buckets.next(lfu.freqs) {
+		lfu.removeEntry(lfu.entries.next(lfu.buckets.at(b).entries), EvictRemoved)
+	}
+}
+
+func (lfu *LFU[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for b := lfu.buckets.next(lfu.freqs); b != lfu.freqs; b = lfu.buckets.next(b) {
+			l := lfu.buckets.at(b).entries
+			for i := lfu.entries.next(l); i != l; i = lfu.entries.next(i) {
+				entry := lfu.entries.at(i)
+				if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (lfu *LFU[K, V]) Len() int {
+	return len(lfu.index)
+}
+
+func (lfu *LFU[K, V]) Size() int64 {
+	return lfu.curCost
+}
+
+// newBucket inserts an empty bucket of %freq after the bucket %at.
+func (lfu *LFU[K, V]) newBucket(freq int, at int32) int32 {
+	b := lfu.buckets.alloc(lfuBucket{freq: freq, entries: lfu.entries.newList()})
+	lfu.buckets.insertAfter(b, at)
+	return b
+}
+
+// releaseBucket drops the bucket %b if it has no entry left.
+func (lfu *LFU[K, V]) releaseBucket(b int32) {
+	l := lfu.buckets.at(b).entries
+	if !lfu.entries.empty(l) {
+		return
+	}
+	lfu.entries.release(l)
+	lfu.buckets.unlink(b)
+	lfu.buckets.release(b)
+}
+
+// pushBack moves the entry %i at the back of the bucket %b.
+func (lfu *LFU[K, V]) pushBack(b, i int32) {
+	l := lfu.buckets.at(b).entries
+	lfu.entries.moveAfter(i, lfu.entries.prev(l))
+	lfu.entries.at(i).bucket = b
+}
+
+func (lfu *LFU[K, V]) increaseFreq(i int32) {
+	b := lfu.entries.at(i).bucket
+	freq := lfu.buckets.at(b).freq + 1
+
+	// insert into the bucket of freq+1.
+	next := lfu.buckets.next(b)
+	if next == lfu.freqs || lfu.buckets.at(next).freq != freq {
+		next = lfu.newBucket(freq, b)
+	}
+	lfu.pushBack(next, i)
+	lfu.releaseBucket(b)
+}
+
+// maybeDecay halves the frequencies of all entries once per elapsed decay period,
+// so that the entries which were popular long ago are eventually evicted.
+func (lfu *LFU[K, V]) maybeDecay() {
+	period := lfu.opts.DecayPeriod
+	if period <= 0 {
+		return
+	}
+	periods := time.Since(lfu.lastDecay) / period
+	if periods == 0 {
+		return
+	}
+	lfu.lastDecay = lfu.lastDecay.Add(periods * period)
+	lfu.decay(uint(min(periods, 63)))
+}
+
+// decay divides the frequencies of all entries by 2^shift, keeping them at least 1.
+// Buckets whose frequencies become equal are merged, in the order they were.
+func (lfu *LFU[K, V]) decay(shift uint) {
+	prev := lfu.freqs
+	for b := lfu.buckets.next(lfu.freqs); b != lfu.freqs; {
+		next := lfu.buckets.next(b)
+		freq := max(1, lfu.buckets.at(b).freq>>shift)
+
+		if prev != lfu.freqs && lfu.buckets.at(prev).freq == freq {
+			l := lfu.buckets.at(b).entries
+			for i := lfu.entries.next(l); i != l; i = lfu.entries.next(l) {
+				lfu.pushBack(prev, i)
+			}
+			lfu.releaseBucket(b)
+		} else {
+			lfu.buckets.at(b).freq = freq
+			prev = b
+		}
+		b = next
+	}
+}
+
+func (lfu *LFU[K, V]) removeEntry(i int32, reason EvictReason) {
+	entry := *lfu.entries.at(i)
+	lfu.curCost -= entry.cost
+	delete(lfu.index, entry.key)
+	lfu.entries.unlink(i)
+	lfu.entries.release(i)
+	lfu.releaseBucket(entry.bucket)
+	lfu.opts.evicted(entry.key, entry.value, reason)
+}
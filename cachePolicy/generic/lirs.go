@@ -0,0 +1,346 @@
+package generic
+
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+// Share of the cost given to the resident HIR pairs.
+const lirsHIRRatio = 0.01
+
+type lirsStatus uint8
+
+const (
+	lirsLIR         lirsStatus = iota // a resident pair of low inter-reference recency
+	lirsHIR                           // a resident pair of high inter-reference recency
+	lirsNonResident                   // the history of an evicted HIR pair
+)
+
+type lirsEntry[K comparable, V any] struct {
+	key    K
+	value  V // zero for a non-resident key
+	cost   int64
+	expire time.Time
+	status lirsStatus
+	stack  *list.Element // in the stack, nil if the entry is not in it
+	queue  *list.Element // in the queue of HIR pairs or the ghost list
+}
+
+// LIRS implements Low Inter-reference Recency Set.
+// Pairs are told apart by their inter-reference recency, the number of other keys accessed
+// between their last two accesses. Those of low recency (LIR) take most of the cost,
+// and the few others (HIR) are evicted first, in FIFO order. The stack orders by recency
+// the LIR pairs and the HIR keys, resident or not, accessed more recently than the least recent LIR pair;
+// a HIR key accessed again while it is in the stack becomes LIR, and the least recent LIR pair becomes HIR.
+// Unlike LRU, a loop over more keys than the cache holds keeps hitting the LIR pairs.
+type LIRS[K comparable, V any] struct {
+	opts    Options[K, V]
+	entries map[K]*lirsEntry[K, V]
+	stack   *list.List // front is the most recently accessed
+	hir     *costList  // the resident HIR pairs, front is the newest
+	ghosts  *costList  // the non-resident keys, front is the newest
+
+	// The number and total cost of the LIR pairs, and the share of MaxCost they may take.
+	lirLen  int
+	lirCost int64
+	lirMax  int64
+}
+
+func NewLIRS[K comparable, V any](opts Options[K, V]) *LIRS[K, V] {
+	return &LIRS[K, V]{
+		opts:    opts,
+		entries: make(map[K]*lirsEntry[K, V]),
+		stack:   list.New(),
+		hir:     newCostList(),
+		ghosts:  newCostList(),
+		lirMax:  lirsMaxCost(opts.MaxCost),
+	}
+}
+
+// lirsMaxCost returns the share of %maxCost of the LIR pairs.
+func lirsMaxCost(maxCost int64) int64 {
+	return maxCost - max(int64(float64(maxCost)*lirsHIRRatio), 1)
+}
+
+func (c *LIRS[K, V]) Get(key K) (value V, ok bool) {
+	entry, ok := c.entries[key]
+	if !ok || entry.status == lirsNonResident {
+		return value, false
+	}
+	if expiredNow(entry.expire) {
+		c.remove(entry, EvictExpired)
+		return value, false
+	}
+	c.access(entry)
+	return entry.value, true
+}
+
+func (c *LIRS[K, V]) Add(key K, value V) error {
+	return c.AddWithExpire(key, value, time.Time{})
+}
+
+func (c *LIRS[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := c.opts.cost(key, value)
+	if err := c.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	entry, ok := c.entries[key]
+	if ok && entry.status != lirsNonResident {
+		old := entry.value
+		if entry.status == lirsLIR {
+			c.lirCost += cost - entry.cost
+		} else {
+			c.hir.cost += cost - entry.cost
+		}
+		entry.value, entry.cost, entry.expire = value, cost, expire
+		c.access(entry)
+		c.opts.evicted(key, old, EvictReplaced)
+		c.balance()
+		c.makeRoom(0)
+		return nil
+	}
+
+	// a non-resident key back while it is in the stack has a low inter-reference recency.
+	// Its history is dropped first, so that making room cannot prune it.
+	recent := ok
+	if ok {
+		c.forget(entry)
+	}
+
+	c.makeRoom(cost)
+	entry = &lirsEntry[K, V]{key: key, value: value, cost: cost, expire: expire}
+	c.entries[key] = entry
+	entry.stack = c.stack.PushFront(entry)
+	if recent {
+		c.setLIR(entry)
+		c.balance()
+	} else if c.opts.MaxCost == 0 || c.lirCost+cost <= c.lirMax {
+		// the LIR pairs do not fill their share yet.
+		c.setLIR(entry)
+	} else {
+		c.setHIR(entry)
+	}
+	return nil
+}
+
+func (c *LIRS[K, V]) Evict() {
+	if c.hir.Len() == 0 {
+		if c.lirLen == 0 {
+			return
+		}
+		c.demote()
+	}
+	entry := c.hir.Back().Value.(*lirsEntry[K, V])
+	c.hir.cost -= entry.cost
+	c.hir.Remove(entry.queue)
+	entry.queue = nil
+	value := entry.value
+
+	if entry.stack == nil {
+		delete(c.entries, entry.key)
+	} else {
+		var zero V
+		entry.value, entry.expire, entry.status = zero, time.Time{}, lirsNonResident
+		entry.queue = c.ghosts.PushFront(entry)
+		c.ghosts.cost += entry.cost
+		c.trimGhosts()
+	}
+	c.opts.evicted(entry.key, value, EvictCapacity)
+}
+
+func (c *LIRS[K, V]) Remove(key K) bool {
+	entry, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	if entry.status == lirsNonResident {
+		c.forget(entry)
+		return false
+	}
+	c.remove(entry, EvictRemoved)
+	return true
+}
+
+func (c *LIRS[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, entry := range c.entries {
+		if entry.status != lirsNonResident && expired(entry.expire, now) {
+			c.remove(entry, EvictExpired)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *LIRS[K, V]) Peek(key K) (value V, ok bool) {
+	if entry, ok := c.entries[key]; ok && entry.status != lirsNonResident && !expiredNow(entry.expire) {
+		return entry.value, true
+	}
+	return value, false
+}
+
+func (c *LIRS[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+func (c *LIRS[K, V]) Resize(maxCost int64) int {
+	n := c.Len()
+	c.opts.MaxCost = maxCost
+	c.lirMax = lirsMaxCost(maxCost)
+	c.balance()
+	c.makeRoom(0)
+	c.trimGhosts()
+	return n - c.Len()
+}
+
+func (c *LIRS[K, V]) Purge() {
+	for _, entry := range c.entries {
+		if entry.status == lirsNonResident {
+			c.forget(entry)
+		} else {
+			c.remove(entry, EvictRemoved)
+		}
+	}
+}
+
+// All yields the resident HIR pairs first, then the LIR pairs from the least recently accessed.
+func (c *LIRS[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for elem := c.hir.Back(); elem != nil; elem = elem.Prev() {
+			entry := elem.Value.(*lirsEntry[K, V])
+			if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+				return
+			}
+		}
+		for elem := c.stack.Back(); elem != nil; elem = elem.Prev() {
+			entry := elem.Value.(*lirsEntry[K, V])
+			if entry.status == lirsLIR && !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *LIRS[K, V]) Len() int {
+	return c.lirLen + c.hir.Len()
+}
+
+func (c *LIRS[K, V]) Size() int64 {
+	return c.lirCost + c.hir.cost
+}
+
+// access records a hit on the resident %entry.
+func (c *LIRS[K, V]) access(entry *lirsEntry[K, V]) {
+	switch {
+	case entry.status == lirsLIR:
+		bottom := c.stack.Back() == entry.stack
+		c.stack.MoveToFront(entry.stack)
+		if bottom {
+			c.prune()
+		}
+	case entry.stack != nil:
+		// accessed again while in the stack: its inter-reference recency is low.
+		c.hir.cost -= entry.cost
+		c.hir.Remove(entry.queue)
+		entry.queue = nil
+		c.stack.MoveToFront(entry.stack)
+		c.setLIR(entry)
+		c.balance()
+	default:
+		entry.stack = c.stack.PushFront(entry)
+		c.hir.MoveToFront(entry.queue)
+	}
+}
+
+// setLIR makes %entry, at the top of the stack, a LIR pair. The HIR keys below the
+// bottom-most LIR pair are pruned, so that demote always finds a LIR pair at the bottom.
+func (c *LIRS[K, V]) setLIR(entry *lirsEntry[K, V]) {
+	entry.status = lirsLIR
+	c.lirLen++
+	c.lirCost += entry.cost
+	c.prune()
+}
+
+// setHIR puts the resident %entry at the back of the queue of HIR pairs.
+func (c *LIRS[K, V]) setHIR(entry *lirsEntry[K, V]) {
+	entry.status = lirsHIR
+	entry.queue = c.hir.PushFront(entry)
+	c.hir.cost += entry.cost
+}
+
+// balance demotes LIR pairs until they fit in their share of MaxCost.
+func (c *LIRS[K, V]) balance() {
+	for c.opts.MaxCost != 0 && c.lirLen > 0 && c.lirCost > c.lirMax {
+		c.demote()
+	}
+}
+
+// demote turns the least recently accessed LIR pair, at the bottom of the stack, into a HIR one.
+func (c *LIRS[K, V]) demote() {
+	entry := c.stack.Back().Value.(*lirsEntry[K, V])
+	c.lirLen--
+	c.lirCost -= entry.cost
+	c.stack.Remove(entry.stack)
+	entry.stack = nil
+	c.setHIR(entry)
+	c.prune()
+}
+
+// prune drops the HIR keys from the bottom of the stack, so that it ends with a LIR pair.
+func (c *LIRS[K, V]) prune() {
+	for elem := c.stack.Back(); elem != nil; elem = c.stack.Back() {
+		entry := elem.Value.(*lirsEntry[K, V])
+		if entry.status == lirsLIR {
+			return
+		}
+		if entry.status == lirsNonResident {
+			c.forget(entry)
+		} else {
+			c.stack.Remove(elem)
+			entry.stack = nil
+		}
+	}
+}
+
+// makeRoom evicts until %need more cost fits in the cache.
+func (c *LIRS[K, V]) makeRoom(need int64) {
+	for c.opts.MaxCost != 0 && c.Len() > 0 && c.Size()+need > c.opts.MaxCost {
+		c.Evict()
+	}
+}
+
+// trimGhosts keeps the history of non-resident keys within as much cost as the cache may hold.
+func (c *LIRS[K, V]) trimGhosts() {
+	for c.ghosts.Len() > 0 && c.ghosts.cost > c.opts.MaxCost {
+		c.forget(c.ghosts.Back().Value.(*lirsEntry[K, V]))
+	}
+}
+
+// remove drops a resident pair and reports it for %reason.
+func (c *LIRS[K, V]) remove(entry *lirsEntry[K, V], reason EvictReason) {
+	if entry.status == lirsLIR {
+		c.lirLen--
+		c.lirCost -= entry.cost
+	} else {
+		c.hir.cost -= entry.cost
+		c.hir.Remove(entry.queue)
+	}
+	delete(c.entries, entry.key)
+	if entry.stack != nil {
+		c.stack.Remove(entry.stack)
+		c.prune()
+	}
+	c.opts.evicted(entry.key, entry.value, reason)
+}
+
+// forget drops the history of a non-resident key.
+func (c *LIRS[K, V]) forget(entry *lirsEntry[K, V]) {
+	c.ghosts.cost -= entry.cost
+	c.ghosts.Remove(entry.queue)
+	c.stack.Remove(entry.stack)
+	delete(c.entries, entry.key)
+}
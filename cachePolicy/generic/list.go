@@ -0,0 +1,13 @@
+package generic
+
+import "container/list"
+
+// costList is a list of entries along with their total cost.
+type costList struct {
+	*list.List
+	cost int64
+}
+
+func newCostList() *costList {
+	return &costList{List: list.New()}
+}
@@ -0,0 +1,160 @@
+package generic
+
+import (
+	"iter"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	cost   int64
+	expire time.Time
+}
+
+// LRU evicts the least recently used pair first.
+// Its entries are kept in a pool, so that Add and Get do not allocate once it is full.
+type LRU[K comparable, V any] struct {
+	opts    Options[K, V]
+	entries pool[lruEntry[K, V]]
+	index   map[K]int32
+	used    int32 // the sentinel of the list of entries, front is the most recently used
+
+	// The current total cost of the pairs.
+	curCost int64
+}
+
+func NewLRU[K comparable, V any](opts Options[K, V]) *LRU[K, V] {
+	lru := &LRU[K, V]{
+		opts:    opts,
+		entries: newPool[lruEntry[K, V]](),
+		index:   make(map[K]int32),
+	}
+	lru.used = lru.entries.newList()
+	return lru
+}
+
+func (lru *LRU[K, V]) Get(key K) (value V, ok bool) {
+	if i, ok := lru.index[key]; ok {
+		entry := lru.entries.at(i)
+		if expiredNow(entry.expire) {
+			lru.removeEntry(i, EvictExpired)
+			return value, false
+		}
+		lru.entries.moveAfter(i, lru.used)
+		return entry.value, true
+	}
+	return value, false
+}
+
+func (lru *LRU[K, V]) Add(key K, value V) error {
+	return lru.AddWithExpire(key, value, time.Time{})
+}
+
+func (lru *LRU[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := lru.opts.cost(key, value)
+	if err := lru.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	if i, ok := lru.index[key]; ok {
+		entry := lru.entries.at(i)
+		old := entry.value
+		lru.curCost += cost - entry.cost
+		entry.value, entry.cost, entry.expire = value, cost, expire
+		lru.entries.moveAfter(i, lru.used)
+		lru.opts.evicted(key, old, EvictReplaced)
+	} else {
+		i := lru.entries.alloc(lruEntry[K, V]{key, value, cost, expire})
+		lru.entries.insertAfter(i, lru.used)
+		lru.index[key] = i
+		lru.curCost += cost
+	}
+
+	for lru.opts.MaxCost != 0 && lru.curCost > lru.opts.MaxCost {
+		lru.Evict()
+	}
+	return nil
+}
+
+func (lru *LRU[K, V]) Evict() {
+	if back := lru.entries.prev(lru.used); back != lru.used {
+		lru.removeEntry(back, EvictCapacity)
+	}
+}
+
+func (lru *LRU[K, V]) Remove(key K) bool {
+	if i, ok := lru.index[key]; ok {
+		lru.removeEntry(i, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (lru *LRU[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for i := lru.entries.next(lru.used); i != lru.used; {
+		next := lru.entries.next(i)
+		if expired(lru.entries.at(i).expire, now) {
+			lru.removeEntry(i, EvictExpired)
+			removed++
+		}
+		i = next
+	}
+	return removed
+}
+
+func (lru *LRU[K, V]) Peek(key K) (value V, ok bool) {
+	if i, ok := lru.index[key]; ok {
+		entry := lru.entries.at(i)
+		if !expiredNow(entry.expire) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (lru *LRU[K, V]) Contains(key K) bool {
+	_, ok := lru.Peek(key)
+	return ok
+}
+
+func (lru *LRU[K, V]) Resize(maxCost int64) int {
+	lru.opts.MaxCost = maxCost
+	return shrink(lru, maxCost)
+}
+
+func (lru *LRU[K, V]) Purge() {
+	for i := lru.entries.prev(lru.used); i != lru.used; i = lru.entries.prev(lru.used) {
+		lru.removeEntry(i, EvictRemoved)
+	}
+}
+
+func (lru *LRU[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for i := lru.entries.prev(lru.used); i != lru.used; i = lru.entries.prev(i) {
+			entry := lru.entries.at(i)
+			if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+func (lru *LRU[K, V]) Len() int {
+	return len(lru.index)
+}
+
+func (lru *LRU[K, V]) Size() int64 {
+	return lru.curCost
+}
+
+func (lru *LRU[K, V]) removeEntry(i int32, reason EvictReason) {
+	entry := *lru.entries.at(i)
+	lru.curCost -= entry.cost
+	delete(lru.index, entry.key)
+	lru.entries.unlink(i)
+	lru.entries.release(i)
+	lru.opts.evicted(entry.key, entry.value, reason)
+}
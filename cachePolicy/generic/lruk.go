@@ -0,0 +1,285 @@
+package generic
+
+import (
+	"container/heap"
+	"container/list"
+	"iter"
+	"slices"
+	"time"
+)
+
+// DefaultLRUK is the number of accesses LRU-K remembers for each key when none is given.
+const DefaultLRUK = 2
+
+type lruKEntry[K comparable, V any] struct {
+	key    K
+	value  V // zero for a non-resident key
+	cost   int64
+	expire time.Time
+
+	// The times of the last K accesses, most recent first, fewer for a key not accessed K times yet.
+	history []uint64
+	kth     uint64        // the time of the K-th most recent access, 0 if there is none
+	index   int           // in the heap, for a resident pair
+	ghost   *list.Element // in the ghost list, for a non-resident key
+}
+
+// LRUK implements LRU-K, which evicts the pair whose K-th most recent access is the oldest first.
+// Pairs accessed fewer than K times go before all the others, the least recently used one first,
+// so that a scan of keys used only once does not evict the pairs used repeatedly.
+// The history of an evicted key is kept for a while, thus a key which comes back soon
+// is known to have been accessed before.
+type LRUK[K comparable, V any] struct {
+	opts    Options[K, V]
+	k       int
+	entries map[K]*lruKEntry[K, V]
+	heap    lruKHeap[K, V]
+	ghosts  *costList // the non-resident keys, front is the newest
+
+	// The logical time, increased on each access.
+	clock uint64
+
+	// The current total cost of the resident pairs.
+	curCost int64
+}
+
+// NewLRUK creates an LRU-K cache remembering the last %k accesses of each key,
+// DefaultLRUK of them if %k is not positive.
+func NewLRUK[K comparable, V any](opts Options[K, V], k int) *LRUK[K, V] {
+	if k <= 0 {
+		k = DefaultLRUK
+	}
+	return &LRUK[K, V]{
+		opts:    opts,
+		k:       k,
+		entries: make(map[K]*lruKEntry[K, V]),
+		ghosts:  newCostList(),
+	}
+}
+
+func (c *LRUK[K, V]) Get(key K) (value V, ok bool) {
+	entry, ok := c.entries[key]
+	if !ok || entry.ghost != nil {
+		return value, false
+	}
+	if expiredNow(entry.expire) {
+		c.remove(entry, EvictExpired)
+		return value, false
+	}
+	c.access(entry)
+	heap.Fix(&c.heap, entry.index)
+	return entry.value, true
+}
+
+func (c *LRUK[K, V]) Add(key K, value V) error {
+	return c.AddWithExpire(key, value, time.Time{})
+}
+
+func (c *LRUK[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := c.opts.cost(key, value)
+	if err := c.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	maxCost := c.opts.MaxCost
+	entry, ok := c.entries[key]
+	if ok && entry.ghost == nil {
+		old := entry.value
+		c.curCost += cost - entry.cost
+		entry.value, entry.cost, entry.expire = value, cost, expire
+		c.access(entry)
+		heap.Fix(&c.heap, entry.index)
+		c.opts.evicted(key, old, EvictReplaced)
+		// the pair itself may be evicted if it is still the first to go.
+		for maxCost != 0 && c.curCost > maxCost {
+			c.Evict()
+		}
+		return nil
+	}
+
+	if ok {
+		// a non-resident key keeps its history.
+		c.ghosts.cost -= entry.cost
+		c.ghosts.Remove(entry.ghost)
+		entry.ghost = nil
+	} else {
+		entry = &lruKEntry[K, V]{key: key, history: make([]uint64, 0, c.k)}
+	}
+	for maxCost != 0 && len(c.heap) > 0 && c.curCost+cost > maxCost {
+		c.Evict()
+	}
+	entry.value, entry.cost, entry.expire = value, cost, expire
+	c.access(entry)
+	c.entries[key] = entry
+	heap.Push(&c.heap, entry)
+	c.curCost += cost
+	return nil
+}
+
+func (c *LRUK[K, V]) Evict() {
+	if len(c.heap) == 0 {
+		return
+	}
+	entry := heap.Pop(&c.heap).(*lruKEntry[K, V])
+	c.curCost -= entry.cost
+
+	value := entry.value
+	var zero V
+	entry.value, entry.expire = zero, time.Time{}
+	entry.ghost = c.ghosts.PushFront(entry)
+	c.ghosts.cost += entry.cost
+	c.trimGhosts()
+
+	c.opts.evicted(entry.key, value, EvictCapacity)
+}
+
+func (c *LRUK[K, V]) Remove(key K) bool {
+	entry, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	if entry.ghost != nil {
+		c.forget(entry)
+		return false
+	}
+	c.remove(entry, EvictRemoved)
+	return true
+}
+
+func (c *LRUK[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, entry := range slices.Clone(c.heap) {
+		if expired(entry.expire, now) {
+			c.remove(entry, EvictExpired)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *LRUK[K, V]) Peek(key K) (value V, ok bool) {
+	if entry, ok := c.entries[key]; ok && entry.ghost == nil && !expiredNow(entry.expire) {
+		return entry.value, true
+	}
+	return value, false
+}
+
+func (c *LRUK[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+func (c *LRUK[K, V]) Resize(maxCost int64) int {
+	c.opts.MaxCost = maxCost
+	n := shrink(c, maxCost)
+	c.trimGhosts()
+	return n
+}
+
+func (c *LRUK[K, V]) Purge() {
+	for len(c.heap) > 0 {
+		c.remove(c.heap[0], EvictRemoved)
+	}
+	for elem := c.ghosts.Back(); elem != nil; elem = c.ghosts.Back() {
+		c.forget(elem.Value.(*lruKEntry[K, V]))
+	}
+}
+
+func (c *LRUK[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		sorted := slices.Clone(c.heap)
+		slices.SortFunc(sorted, func(a, b *lruKEntry[K, V]) int {
+			if a.before(b) {
+				return -1
+			}
+			return 1
+		})
+		for _, entry := range sorted {
+			if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *LRUK[K, V]) Len() int {
+	return len(c.heap)
+}
+
+func (c *LRUK[K, V]) Size() int64 {
+	return c.curCost
+}
+
+// access records an access to %entry, which must be fixed in the heap afterwards.
+func (c *LRUK[K, V]) access(entry *lruKEntry[K, V]) {
+	c.clock++
+	if len(entry.history) < c.k {
+		entry.history = append(entry.history, 0)
+	}
+	copy(entry.history[1:], entry.history)
+	entry.history[0] = c.clock
+	if len(entry.history) == c.k {
+		entry.kth = entry.history[c.k-1]
+	}
+}
+
+// trimGhosts keeps the history of non-resident keys within as much cost as the cache may hold.
+func (c *LRUK[K, V]) trimGhosts() {
+	for c.ghosts.Len() > 0 && c.ghosts.cost > c.opts.MaxCost {
+		c.forget(c.ghosts.Back().Value.(*lruKEntry[K, V]))
+	}
+}
+
+// remove drops a resident pair along with its history and reports it for %reason.
+func (c *LRUK[K, V]) remove(entry *lruKEntry[K, V], reason EvictReason) {
+	heap.Remove(&c.heap, entry.index)
+	delete(c.entries, entry.key)
+	c.curCost -= entry.cost
+	c.opts.evicted(entry.key, entry.value, reason)
+}
+
+// forget drops the history of a non-resident key.
+func (c *LRUK[K, V]) forget(entry *lruKEntry[K, V]) {
+	c.ghosts.cost -= entry.cost
+	c.ghosts.Remove(entry.ghost)
+	delete(c.entries, entry.key)
+}
+
+// before reports whether %e is evicted before %other.
+func (e *lruKEntry[K, V]) before(other *lruKEntry[K, V]) bool {
+	if e.kth != other.kth {
+		return e.kth < other.kth
+	}
+	return e.history[0] < other.history[0]
+}
+
+// lruKHeap is a min-heap of the resident entries by eviction order, for container/heap.
+type lruKHeap[K comparable, V any] []*lruKEntry[K, V]
+
+func (h lruKHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h lruKHeap[K, V]) Less(i, j int) bool {
+	return h[i].before(h[j])
+}
+
+func (h lruKHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *lruKHeap[K, V]) Push(x any) {
+	entry := x.(*lruKEntry[K, V])
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *lruKHeap[K, V]) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
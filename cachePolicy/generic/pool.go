@@ -0,0 +1,90 @@
+package generic
+
+// nilNode is the index of no node.
+const nilNode = -1
+
+// A pool holds nodes in a slice, linked into circular doubly linked lists by their indexes,
+// so that they are neither allocated one by one nor pointers for the GC to follow.
+// Each list starts with a sentinel node. Released nodes are kept in a free list
+// and reused, thus a pool stops allocating once it has reached its largest size.
+// A pointer returned by at is only valid until the next alloc.
+type pool[T any] struct {
+	nodes []node[T]
+	free  int32 // the first released node, linked by next
+}
+
+type node[T any] struct {
+	prev, next int32
+	value      T
+}
+
+func newPool[T any]() pool[T] {
+	return pool[T]{free: nilNode}
+}
+
+// alloc returns an unlinked node holding %value.
+func (p *pool[T]) alloc(value T) int32 {
+	i := p.free
+	if i == nilNode {
+		i = int32(len(p.nodes))
+		p.nodes = append(p.nodes, node[T]{})
+	} else {
+		p.free = p.nodes[i].next
+	}
+	p.nodes[i] = node[T]{prev: i, next: i, value: value}
+	return i
+}
+
+// release puts the unlinked node %i back into the free list, dropping its value.
+func (p *pool[T]) release(i int32) {
+	p.nodes[i] = node[T]{prev: nilNode, next: p.free}
+	p.free = i
+}
+
+// newList returns the sentinel of a new empty list.
+func (p *pool[T]) newList() int32 {
+	var zero T
+	return p.alloc(zero)
+}
+
+func (p *pool[T]) at(i int32) *T {
+	return &p.nodes[i].value
+}
+
+func (p *pool[T]) next(i int32) int32 {
+	return p.nodes[i].next
+}
+
+func (p *pool[T]) prev(i int32) int32 {
+	return p.nodes[i].prev
+}
+
+// empty reports whether the list of sentinel %l has no node.
+func (p *pool[T]) empty(l int32) bool {
+	return p.nodes[l].next == l
+}
+
+// insertAfter links the unlinked node %i after %at.
+func (p *pool[T]) insertAfter(i, at int32) {
+	next := p.nodes[at].next
+	p.nodes[i].prev, p.nodes[i].next = at, next
+	p.nodes[at].next = i
+	p.nodes[next].prev = i
+}
+
+// unlink takes %i out of its list.
+func (p *pool[T]) unlink(i int32) {
+	prev, next := p.nodes[i].prev, p.nodes[i].next
+	p.nodes[prev].next = next
+	p.nodes[next].prev = prev
+	p.nodes[i].prev, p.nodes[i].next = i, i
+}
+
+// moveAfter moves %i after %at, which may be in another list.
+func (p *pool[T]) moveAfter(i, at int32) {
+	if i == at || p.nodes[at].next == i {
+		return
+	}
+	p.unlink(i)
+	p.insertAfter(i, at)
+}
@@ -0,0 +1,266 @@
+package generic
+
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+const (
+	// Share of the cost given to the small queue.
+	s3FIFOSmallRatio = 0.1
+	// Access counters saturate at this value.
+	s3FIFOMaxFreq = 3
+)
+
+type s3FIFOEntry[K comparable, V any] struct {
+	key    K
+	value  V // zero for a ghost entry
+	cost   int64
+	expire time.Time
+	freq   uint8
+	list   *costList
+}
+
+// S3FIFO implements S3-FIFO.
+// New pairs enter a small FIFO queue, and only those accessed again before leaving it
+// are moved to the main FIFO queue, the others are evicted and remembered in a ghost queue.
+// A pair added again while it is a ghost goes directly to the main queue.
+// Pairs of the main queue are reinserted as long as they are accessed, with a decreasing counter.
+// A hit only increments a counter, so that Get never reorders the queues.
+type S3FIFO[K comparable, V any] struct {
+	opts               Options[K, V]
+	small, main, ghost *costList // front is the newest pair
+	entries            map[K]*list.Element
+
+	// The share of MaxCost of the small queue.
+	smallCost int64
+}
+
+func NewS3FIFO[K comparable, V any](opts Options[K, V]) *S3FIFO[K, V] {
+	return &S3FIFO[K, V]{
+		opts:      opts,
+		small:     newCostList(),
+		main:      newCostList(),
+		ghost:     newCostList(),
+		entries:   make(map[K]*list.Element),
+		smallCost: int64(float64(opts.MaxCost) * s3FIFOSmallRatio),
+	}
+}
+
+func (c *S3FIFO[K, V]) Get(key K) (value V, ok bool) {
+	elem, ok := c.entries[key]
+	if !ok || elem.Value.(*s3FIFOEntry[K, V]).list == c.ghost {
+		return value, false
+	}
+
+	entry := elem.Value.(*s3FIFOEntry[K, V])
+	if expiredNow(entry.expire) {
+		c.remove(elem, EvictExpired)
+		return value, false
+	}
+	entry.freq = min(entry.freq+1, s3FIFOMaxFreq)
+	return entry.value, true
+}
+
+func (c *S3FIFO[K, V]) Add(key K, value V) error {
+	return c.AddWithExpire(key, value, time.Time{})
+}
+
+func (c *S3FIFO[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := c.opts.cost(key, value)
+	if err := c.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	target := c.small
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*s3FIFOEntry[K, V])
+		if entry.list != c.ghost {
+			old := entry.value
+			entry.list.cost += cost - entry.cost
+			entry.value, entry.cost, entry.expire = value, cost, expire
+			entry.freq = min(entry.freq+1, s3FIFOMaxFreq)
+			c.opts.evicted(key, old, EvictReplaced)
+			c.makeRoom(0)
+			return nil
+		}
+		// seen recently: it deserves the main queue.
+		c.unlink(elem)
+		target = c.main
+	}
+
+	c.makeRoom(cost)
+	c.link(target, &s3FIFOEntry[K, V]{
+		key:    key,
+		value:  value,
+		cost:   cost,
+		expire: expire,
+	})
+	return nil
+}
+
+func (c *S3FIFO[K, V]) Evict() {
+	for n := c.Len(); n > 0 && c.Len() == n; {
+		c.evictOnce()
+	}
+}
+
+func (c *S3FIFO[K, V]) Remove(key K) bool {
+	if elem, ok := c.entries[key]; ok {
+		if elem.Value.(*s3FIFOEntry[K, V]).list == c.ghost {
+			c.unlink(elem)
+			return false
+		}
+		c.remove(elem, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (c *S3FIFO[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, l := range []*costList{c.small, c.main} {
+		for elem := l.Front(); elem != nil; {
+			next := elem.Next()
+			if expired(elem.Value.(*s3FIFOEntry[K, V]).expire, now) {
+				c.remove(elem, EvictExpired)
+				removed++
+			}
+			elem = next
+		}
+	}
+	return removed
+}
+
+func (c *S3FIFO[K, V]) Peek(key K) (value V, ok bool) {
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*s3FIFOEntry[K, V])
+		if entry.list != c.ghost && !expiredNow(entry.expire) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (c *S3FIFO[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+func (c *S3FIFO[K, V]) Resize(maxCost int64) int {
+	n := c.Len()
+	c.opts.MaxCost = maxCost
+	c.smallCost = int64(float64(maxCost) * s3FIFOSmallRatio)
+	c.makeRoom(0)
+	c.trimGhost()
+	return n - c.Len()
+}
+
+func (c *S3FIFO[K, V]) Purge() {
+	for _, l := range []*costList{c.small, c.main} {
+		for elem := l.Back(); elem != nil; elem = l.Back() {
+			c.remove(elem, EvictRemoved)
+		}
+	}
+	for elem := c.ghost.Back(); elem != nil; elem = c.ghost.Back() {
+		c.unlink(elem)
+	}
+}
+
+func (c *S3FIFO[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for _, l := range []*costList{c.small, c.main} {
+			for elem := l.Back(); elem != nil; elem = elem.Prev() {
+				entry := elem.Value.(*s3FIFOEntry[K, V])
+				if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *S3FIFO[K, V]) Len() int {
+	return c.small.Len() + c.main.Len()
+}
+
+func (c *S3FIFO[K, V]) Size() int64 {
+	return c.small.cost + c.main.cost
+}
+
+// makeRoom evicts until %need more cost fits in the cache.
+func (c *S3FIFO[K, V]) makeRoom(need int64) {
+	for c.opts.MaxCost != 0 && c.Len() > 0 && c.Size()+need > c.opts.MaxCost {
+		c.evictOnce()
+	}
+}
+
+// evictOnce takes a step of eviction from the small queue if it exceeds its share,
+// from the main one otherwise. A step may only move a pair instead of evicting it.
+func (c *S3FIFO[K, V]) evictOnce() {
+	if c.small.Len() > 0 && (c.small.cost > c.smallCost || c.main.Len() == 0) {
+		c.evictSmall()
+	} else {
+		c.evictMain()
+	}
+}
+
+func (c *S3FIFO[K, V]) evictSmall() {
+	elem := c.small.Back()
+	entry := elem.Value.(*s3FIFOEntry[K, V])
+	c.unlink(elem)
+	if entry.freq > 1 {
+		entry.freq = 0
+		c.link(c.main, entry)
+		return
+	}
+
+	value := entry.value
+	var zero V
+	entry.value, entry.expire = zero, time.Time{}
+	c.link(c.ghost, entry)
+	c.trimGhost()
+
+	c.opts.evicted(entry.key, value, EvictCapacity)
+}
+
+// trimGhost keeps the ghost queue within as much cost as the main queue may hold.
+func (c *S3FIFO[K, V]) trimGhost() {
+	for c.ghost.Len() > 0 && c.ghost.cost > c.opts.MaxCost-c.smallCost {
+		c.unlink(c.ghost.Back())
+	}
+}
+
+func (c *S3FIFO[K, V]) evictMain() {
+	elem := c.main.Back()
+	entry := elem.Value.(*s3FIFOEntry[K, V])
+	if entry.freq > 0 {
+		entry.freq--
+		c.main.MoveToFront(elem)
+		return
+	}
+
+	c.remove(elem, EvictCapacity)
+}
+
+func (c *S3FIFO[K, V]) link(l *costList, entry *s3FIFOEntry[K, V]) {
+	entry.list = l
+	l.cost += entry.cost
+	c.entries[entry.key] = l.PushFront(entry)
+}
+
+// remove drops a resident pair and reports it for %reason.
+func (c *S3FIFO[K, V]) remove(elem *list.Element, reason EvictReason) {
+	entry := elem.Value.(*s3FIFOEntry[K, V])
+	c.unlink(elem)
+	c.opts.evicted(entry.key, entry.value, reason)
+}
+
+func (c *S3FIFO[K, V]) unlink(elem *list.Element) {
+	entry := elem.Value.(*s3FIFOEntry[K, V])
+	entry.list.cost -= entry.cost
+	entry.list.Remove(elem)
+	delete(c.entries, entry.key)
+}
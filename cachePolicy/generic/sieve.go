@@ -0,0 +1,193 @@
+package generic
+
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+type sieveEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	cost    int64
+	expire  time.Time
+	visited bool
+}
+
+// SIEVE implements SIEVE.
+// Pairs are kept in insertion order and a hit only marks the pair as visited,
+// so that Get never reorders the list. To evict, a hand moves from the oldest pair
+// towards the newest one, giving each visited pair a second chance by clearing its mark,
+// and evicts the first pair which is not marked.
+type SIEVE[K comparable, V any] struct {
+	opts     Options[K, V]
+	usedMap  map[K]*list.Element
+	usedList *list.List // front is the newest pair
+	hand     *list.Element
+
+	// The current total cost of the pairs.
+	curCost int64
+}
+
+func NewSIEVE[K comparable, V any](opts Options[K, V]) *SIEVE[K, V] {
+	return &SIEVE[K, V]{
+		opts:     opts,
+		usedMap:  make(map[K]*list.Element),
+		usedList: list.New(),
+	}
+}
+
+func (s *SIEVE[K, V]) Get(key K) (value V, ok bool) {
+	if elem, ok := s.usedMap[key]; ok {
+		entry := elem.Value.(*sieveEntry[K, V])
+		if expiredNow(entry.expire) {
+			s.removeElement(elem, EvictExpired)
+			return value, false
+		}
+		entry.visited = true
+		return entry.value, true
+	}
+	return value, false
+}
+
+func (s *SIEVE[K, V]) Add(key K, value V) error {
+	return s.AddWithExpire(key, value, time.Time{})
+}
+
+func (s *SIEVE[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := s.opts.cost(key, value)
+	if err := s.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	if elem, ok := s.usedMap[key]; ok {
+		entry := elem.Value.(*sieveEntry[K, V])
+		old := entry.value
+		s.curCost += cost - entry.cost
+		entry.value, entry.cost, entry.expire, entry.visited = value, cost, expire, true
+		s.opts.evicted(key, old, EvictReplaced)
+	} else {
+		s.usedMap[key] = s.usedList.PushFront(&sieveEntry[K, V]{
+			key:    key,
+			value:  value,
+			cost:   cost,
+			expire: expire,
+		})
+		s.curCost += cost
+	}
+
+	for s.opts.MaxCost != 0 && s.curCost > s.opts.MaxCost {
+		s.Evict()
+	}
+	return nil
+}
+
+func (s *SIEVE[K, V]) Evict() {
+	if s.usedList.Len() == 0 {
+		return
+	}
+
+	elem := s.hand
+	if elem == nil {
+		elem = s.usedList.Back()
+	}
+	for elem.Value.(*sieveEntry[K, V]).visited {
+		elem.Value.(*sieveEntry[K, V]).visited = false
+		if elem = elem.Prev(); elem == nil {
+			elem = s.usedList.Back()
+		}
+	}
+
+	s.hand = elem
+	s.removeElement(elem, EvictCapacity)
+}
+
+func (s *SIEVE[K, V]) Remove(key K) bool {
+	if elem, ok := s.usedMap[key]; ok {
+		s.removeElement(elem, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (s *SIEVE[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for elem := s.usedList.Front(); elem != nil; {
+		next := elem.Next()
+		if expired(elem.Value.(*sieveEntry[K, V]).expire, now) {
+			s.removeElement(elem, EvictExpired)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+func (s *SIEVE[K, V]) Peek(key K) (value V, ok bool) {
+	if elem, ok := s.usedMap[key]; ok {
+		entry := elem.Value.(*sieveEntry[K, V])
+		if !expiredNow(entry.expire) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (s *SIEVE[K, V]) Contains(key K) bool {
+	_, ok := s.Peek(key)
+	return ok
+}
+
+func (s *SIEVE[K, V]) Resize(maxCost int64) int {
+	s.opts.MaxCost = maxCost
+	return shrink(s, maxCost)
+}
+
+func (s *SIEVE[K, V]) Purge() {
+	for elem := s.usedList.Back(); elem != nil; elem = s.usedList.Back() {
+		s.removeElement(elem, EvictRemoved)
+	}
+}
+
+// All yields the pairs in the order the hand visits them.
+func (s *SIEVE[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		start := s.hand
+		if start == nil {
+			start = s.usedList.Back()
+		}
+		for elem := start; elem != nil; {
+			entry := elem.Value.(*sieveEntry[K, V])
+			if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+				return
+			}
+			if elem = elem.Prev(); elem == nil {
+				elem = s.usedList.Back()
+			}
+			if elem == start {
+				return
+			}
+		}
+	}
+}
+
+func (s *SIEVE[K, V]) Len() int {
+	return len(s.usedMap)
+}
+
+func (s *SIEVE[K, V]) Size() int64 {
+	return s.curCost
+}
+
+func (s *SIEVE[K, V]) removeElement(elem *list.Element, reason EvictReason) {
+	entry := elem.Value.(*sieveEntry[K, V])
+	if s.hand == elem {
+		// the hand goes on with the next newer pair.
+		s.hand = elem.Prev()
+	}
+	s.curCost -= entry.cost
+	delete(s.usedMap, entry.key)
+	s.usedList.Remove(elem)
+	s.opts.evicted(entry.key, entry.value, reason)
+}
@@ -0,0 +1,86 @@
+package generic
+
+const (
+	// Number of rows of a count-min sketch, each row uses an independent hash.
+	cmDepth = 4
+	// Counters saturate at this value, like 4-bit counters.
+	cmMaxCount = 15
+	// Counters are halved once the sketch has been incremented
+	// cmSampleFactor times as many times as it has counters per row.
+	cmSampleFactor = 10
+	cmMinWidth     = 1024
+)
+
+// cmSketch is a count-min sketch which estimates how often a key has been seen recently,
+// given the hash of the key. It ages periodically by halving all of its counters,
+// so that old popularity fades out.
+type cmSketch struct {
+	rows      [cmDepth][]uint8
+	mask      uint64
+	additions int
+}
+
+func newCmSketch(width int) *cmSketch {
+	s := &cmSketch{}
+	s.resize(width)
+	return s
+}
+
+// ensureCapacity grows the sketch so that it fits %n keys without too many collisions.
+// The counters restart from zero when it grows.
+func (s *cmSketch) ensureCapacity(n int) {
+	if uint64(n) > s.mask+1 {
+		s.resize(n)
+	}
+}
+
+func (s *cmSketch) resize(width int) {
+	width = max(width, cmMinWidth)
+	size := 1
+	for size < width {
+		size <<= 1
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, size)
+	}
+	s.mask = uint64(size - 1)
+	s.additions = 0
+}
+
+func (s *cmSketch) increment(h uint64) {
+	for i := range s.rows {
+		idx := s.index(h, i)
+		if s.rows[i][idx] < cmMaxCount {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.additions++
+	if s.additions >= cmSampleFactor*int(s.mask+1) {
+		s.reset()
+	}
+}
+
+func (s *cmSketch) estimate(h uint64) uint8 {
+	count := uint8(cmMaxCount)
+	for i := range s.rows {
+		count = min(count, s.rows[i][s.index(h, i)])
+	}
+	return count
+}
+
+// reset halves all counters.
+func (s *cmSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func (s *cmSketch) index(h uint64, i int) uint64 {
+	// double hashing: derive the index of row i from both halves of h.
+	h1, h2 := h&0xffffffff, h>>32
+	return (h1 + uint64(i)*h2) & s.mask
+}
@@ -0,0 +1,294 @@
+package generic
+
+import (
+	"container/list"
+	"iter"
+	"time"
+)
+
+const (
+	// Share of the cost given to the admission window.
+	tinyLFUWindowRatio = 0.01
+	// Share of the cost of the main cache given to its protected segment.
+	tinyLFUProtectedRatio = 0.8
+)
+
+type tinyLFUEntry[K comparable, V any] struct {
+	key    K
+	hash   uint64
+	value  V
+	cost   int64
+	expire time.Time
+	list   *costList
+}
+
+// TinyLFU implements W-TinyLFU.
+// New pairs enter a small LRU window. A pair leaving the window is a candidate
+// for the main cache, a segmented LRU made of a probation and a protected segment.
+// The candidate is admitted only if a count-min sketch estimates it was accessed
+// more often than the victim it would evict from the main cache,
+// so that one-hit wonders cannot push out valuable pairs.
+type TinyLFU[K comparable, V any] struct {
+	opts                         Options[K, V]
+	window, probation, protected *costList
+	entries                      map[K]*list.Element
+	sketch                       *cmSketch
+	hash                         func(key K) uint64
+
+	// The split of MaxCost among the segments.
+	windowCost, mainCost, protectCost int64
+}
+
+func NewTinyLFU[K comparable, V any](opts Options[K, V]) *TinyLFU[K, V] {
+	c := &TinyLFU[K, V]{
+		opts:      opts,
+		window:    newCostList(),
+		probation: newCostList(),
+		protected: newCostList(),
+		entries:   make(map[K]*list.Element),
+		sketch:    newCmSketch(cmMinWidth),
+		hash:      opts.hasher(),
+	}
+	c.split()
+	return c
+}
+
+// split divides MaxCost among the segments.
+func (c *TinyLFU[K, V]) split() {
+	c.windowCost = int64(float64(c.opts.MaxCost) * tinyLFUWindowRatio)
+	c.mainCost = c.opts.MaxCost - c.windowCost
+	c.protectCost = int64(float64(c.mainCost) * tinyLFUProtectedRatio)
+}
+
+func (c *TinyLFU[K, V]) Get(key K) (value V, ok bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		c.sketch.increment(c.hash(key))
+		return value, false
+	}
+
+	entry := elem.Value.(*tinyLFUEntry[K, V])
+	c.sketch.increment(entry.hash)
+	if expiredNow(entry.expire) {
+		c.remove(elem, EvictExpired)
+		return value, false
+	}
+	c.touch(elem)
+	return entry.value, true
+}
+
+func (c *TinyLFU[K, V]) Add(key K, value V) error {
+	return c.AddWithExpire(key, value, time.Time{})
+}
+
+func (c *TinyLFU[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := c.opts.cost(key, value)
+	if err := c.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*tinyLFUEntry[K, V])
+		old := entry.value
+		entry.list.cost += cost - entry.cost
+		entry.value, entry.cost, entry.expire = value, cost, expire
+		c.touch(elem)
+		c.opts.evicted(key, old, EvictReplaced)
+		c.drainMain()
+		c.drainWindow()
+		return nil
+	}
+
+	h := c.hash(key)
+	c.sketch.increment(h)
+	c.sketch.ensureCapacity(len(c.entries) + 1)
+	c.link(c.window, &tinyLFUEntry[K, V]{
+		key:    key,
+		hash:   h,
+		value:  value,
+		cost:   cost,
+		expire: expire,
+	})
+	c.drainWindow()
+	return nil
+}
+
+func (c *TinyLFU[K, V]) Evict() {
+	for _, l := range []*costList{c.probation, c.window, c.protected} {
+		if l.Len() > 0 {
+			c.remove(l.Back(), EvictCapacity)
+			return
+		}
+	}
+}
+
+func (c *TinyLFU[K, V]) Remove(key K) bool {
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (c *TinyLFU[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, l := range []*costList{c.window, c.probation, c.protected} {
+		for elem := l.Front(); elem != nil; {
+			next := elem.Next()
+			if expired(elem.Value.(*tinyLFUEntry[K, V]).expire, now) {
+				c.remove(elem, EvictExpired)
+				removed++
+			}
+			elem = next
+		}
+	}
+	return removed
+}
+
+func (c *TinyLFU[K, V]) Peek(key K) (value V, ok bool) {
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*tinyLFUEntry[K, V])
+		if !expiredNow(entry.expire) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (c *TinyLFU[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+func (c *TinyLFU[K, V]) Resize(maxCost int64) int {
+	n := c.Len()
+	c.opts.MaxCost = maxCost
+	c.split()
+	c.drainMain()
+	c.drainWindow()
+	return n - c.Len()
+}
+
+func (c *TinyLFU[K, V]) Purge() {
+	for _, l := range []*costList{c.probation, c.window, c.protected} {
+		for elem := l.Back(); elem != nil; elem = l.Back() {
+			c.remove(elem, EvictRemoved)
+		}
+	}
+	c.sketch = newCmSketch(cmMinWidth)
+}
+
+func (c *TinyLFU[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for _, l := range []*costList{c.probation, c.window, c.protected} {
+			for elem := l.Back(); elem != nil; elem = elem.Prev() {
+				entry := elem.Value.(*tinyLFUEntry[K, V])
+				if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *TinyLFU[K, V]) Len() int {
+	return len(c.entries)
+}
+
+func (c *TinyLFU[K, V]) Size() int64 {
+	return c.window.cost + c.probation.cost + c.protected.cost
+}
+
+// touch records an access to a cached pair:
+// a pair of the probation segment is promoted to the protected one.
+func (c *TinyLFU[K, V]) touch(elem *list.Element) {
+	entry := elem.Value.(*tinyLFUEntry[K, V])
+	switch entry.list {
+	case c.probation:
+		c.unlink(elem)
+		c.link(c.protected, entry)
+		// the LRU pairs of the protected segment get a second chance in the probation one.
+		for c.opts.MaxCost != 0 && c.protected.cost > c.protectCost && c.protected.Len() > 1 {
+			demoted := c.protected.Back()
+			c.unlink(demoted)
+			c.link(c.probation, demoted.Value.(*tinyLFUEntry[K, V]))
+		}
+	default:
+		entry.list.MoveToFront(elem)
+	}
+}
+
+// drainWindow moves the LRU pairs out of the window until it fits in its share of the cost,
+// each of them either gets into the main cache or is evicted.
+func (c *TinyLFU[K, V]) drainWindow() {
+	for c.opts.MaxCost != 0 && c.window.cost > c.windowCost {
+		candidate := c.window.Back()
+		entry := candidate.Value.(*tinyLFUEntry[K, V])
+		if !c.admit(entry) {
+			c.remove(candidate, EvictCapacity)
+			continue
+		}
+		c.unlink(candidate)
+		c.link(c.probation, entry)
+	}
+}
+
+// admit makes room in the main cache for %candidate, evicting the victims
+// which are estimated to be used less often than it. It returns false if
+// the candidate is not worth all of its victims, which are then left in place.
+func (c *TinyLFU[K, V]) admit(candidate *tinyLFUEntry[K, V]) bool {
+	if candidate.cost > c.mainCost {
+		return false
+	}
+
+	// the victims are chosen and compared first, so that none is evicted for nothing.
+	freq := c.sketch.estimate(candidate.hash)
+	need := c.probation.cost + c.protected.cost + candidate.cost - c.mainCost
+	var victims []*list.Element
+	for _, l := range []*costList{c.probation, c.protected} {
+		for victim := l.Back(); victim != nil && need > 0; victim = victim.Prev() {
+			entry := victim.Value.(*tinyLFUEntry[K, V])
+			if freq <= c.sketch.estimate(entry.hash) {
+				return false
+			}
+			victims = append(victims, victim)
+			need -= entry.cost
+		}
+	}
+	for _, victim := range victims {
+		c.remove(victim, EvictCapacity)
+	}
+	return true
+}
+
+// drainMain evicts from the main cache until it fits in its share of the cost.
+func (c *TinyLFU[K, V]) drainMain() {
+	for c.opts.MaxCost != 0 && c.probation.cost+c.protected.cost > c.mainCost {
+		victim := c.probation.Back()
+		if victim == nil {
+			victim = c.protected.Back()
+		}
+		c.remove(victim, EvictCapacity)
+	}
+}
+
+// remove drops a pair and reports it for %reason.
+func (c *TinyLFU[K, V]) remove(elem *list.Element, reason EvictReason) {
+	entry := elem.Value.(*tinyLFUEntry[K, V])
+	c.unlink(elem)
+	c.opts.evicted(entry.key, entry.value, reason)
+}
+
+func (c *TinyLFU[K, V]) link(l *costList, entry *tinyLFUEntry[K, V]) {
+	entry.list = l
+	l.cost += entry.cost
+	c.entries[entry.key] = l.PushFront(entry)
+}
+
+func (c *TinyLFU[K, V]) unlink(elem *list.Element) {
+	entry := elem.Value.(*tinyLFUEntry[K, V])
+	entry.list.cost -= entry.cost
+	entry.list.Remove(elem)
+	delete(c.entries, entry.key)
+}
@@ -0,0 +1,14 @@
+package cachePolicy
+
+import "geecache-s/cachePolicy/generic"
+
+// LIRSCache implements Low Inter-reference Recency Set, see generic.LIRS.
+type LIRSCache = generic.LIRS[string, Value]
+
+func init() {
+	Register(LirsPolicy, func(opts Options) Cache { return NewLIRSCache(opts) })
+}
+
+func NewLIRSCache(opts Options) *LIRSCache {
+	return generic.NewLIRS(genericOptions(opts, pairSize))
+}
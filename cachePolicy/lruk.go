@@ -0,0 +1,16 @@
+package cachePolicy
+
+import "geecache-s/cachePolicy/generic"
+
+// LRUKCache implements LRU-K, see generic.LRUK.
+type LRUKCache = generic.LRUK[string, Value]
+
+func init() {
+	Register(LruKPolicy, func(opts Options) Cache { return NewLRUKCache(opts, generic.DefaultLRUK) })
+}
+
+// NewLRUKCache creates an LRU-K cache remembering the last %k accesses of each key,
+// generic.DefaultLRUK of them if %k is not positive.
+func NewLRUKCache(opts Options, k int) *LRUKCache {
+	return generic.NewLRUK(genericOptions(opts, pairSize), k)
+}
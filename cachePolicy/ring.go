@@ -0,0 +1,455 @@
+package cachePolicy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"iter"
+	"math"
+	"time"
+)
+
+const (
+	// hash (8) | expire (8) | key length (4) | value length (4) | flags (1)
+	ringHeaderSize = 25
+	// The arena starts with this many bytes and doubles until it reaches MaxBytes.
+	ringMinArena = 4 << 10
+	// Offsets in the arena are uint32.
+	ringMaxArena = math.MaxUint32
+
+	ringDeleted  = 1 << 0
+	ringAccessed = 1 << 1
+)
+
+// A ValueCodec converts Values to and from bytes,
+// for policies which store them outside of Go objects such as RingCache.
+type ValueCodec interface {
+	// Append the encoding of %value to %dst.
+	AppendValue(dst []byte, value Value) ([]byte, error)
+
+	// Decode a value from %data, which is only valid during the call.
+	DecodeValue(data []byte) (Value, error)
+}
+
+// Bytes is a Value which RingCache can store without any ValueCodec.
+type Bytes []byte
+
+func (b Bytes) Size() int64 {
+	return int64(len(b))
+}
+
+type bytesCodec struct{}
+
+func (bytesCodec) AppendValue(dst []byte, value Value) ([]byte, error) {
+	b, ok := value.(Bytes)
+	if !ok {
+		return nil, fmt.Errorf("cannot store a value of type %T without a ValueCodec", value)
+	}
+	return append(dst, b...), nil
+}
+
+func (bytesCodec) DecodeValue(data []byte) (Value, error) {
+	b := make(Bytes, len(data))
+	copy(b, data)
+	return b, nil
+}
+
+// RingCache packs its pairs into a single byte arena used as a ring buffer, like bigcache
+// or freecache, so that millions of pairs cost the garbage collector a few objects to scan
+// instead of several pointers each. It is indexed by a map from the hash of each key to
+// the offset of its record, which holds no pointer either, and keys are stored in the
+// records to tell colliding hashes apart: a pair evicts the one whose key has the same hash.
+//
+// New records are appended at the head of the ring. When it is full, the record at the tail
+// is evicted, unless it was read since it was written, in which case it is moved to the head
+// once if there is room, which gives it a second chance like CLOCK.
+// Removed and replaced records are only marked deleted, and their bytes are reclaimed when
+// the tail reaches them.
+//
+// Values are stored through Options.Codec, Bytes only if there is none.
+// Size counts the bytes the records really take in the arena, headers included,
+// thus Options.EntryOverhead is ignored. The arena may not exceed 4GB.
+type RingCache struct {
+	opts  Options
+	codec ValueCodec
+	seed  maphash.Seed
+
+	arena []byte
+	index map[uint64]uint32
+	limit int64 // the maximum size of the arena
+
+	// The records are in [tail, head) if the ring is not wrapped,
+	// in [tail, end) then [0, head) if it is.
+	head, tail, end int
+	wrapped         bool
+
+	count int
+	size  int64 // bytes taken by the records which are not deleted
+	buf   []byte
+}
+
+func init() {
+	Register(RingPolicy, func(opts Options) Cache { return NewRingCache(opts) })
+}
+
+func NewRingCache(opts Options) *RingCache {
+	c := &RingCache{
+		opts:  opts,
+		codec: opts.Codec,
+		seed:  maphash.MakeSeed(),
+		index: make(map[uint64]uint32),
+	}
+	if c.codec == nil {
+		c.codec = bytesCodec{}
+	}
+	c.setLimit(opts.MaxBytes)
+	return c
+}
+
+func (c *RingCache) Get(key string) (Value, bool) {
+	off, ok := c.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	if c.expired(off, time.Now()) {
+		c.remove(off, EvictExpired)
+		return nil, false
+	}
+	c.arena[off+24] |= ringAccessed
+	return c.value(off), true
+}
+
+func (c *RingCache) Add(key string, value Value) error {
+	return c.AddWithExpire(key, value, time.Time{})
+}
+
+func (c *RingCache) AddWithExpire(key string, value Value, expire time.Time) error {
+	rec, err := c.encode(key, value, expire)
+	if err != nil {
+		return err
+	}
+	if int64(len(rec)) > c.limit {
+		return fmt.Errorf("the cost of the pair is too large, need less than %d which is %d", c.limit, len(rec))
+	}
+
+	h := maphash.String(c.seed, key)
+	var old Value
+	replaced := false
+	if off, ok := c.index[h]; ok {
+		if c.key(int(off)) == key {
+			old, replaced = c.value(int(off)), true
+			c.unlink(int(off))
+		} else {
+			c.remove(int(off), EvictCapacity)
+		}
+	}
+
+	off := c.makeRoom(len(rec))
+	c.write(off, rec)
+	if replaced {
+		c.evicted(key, old, EvictReplaced)
+	}
+	return nil
+}
+
+func (c *RingCache) Evict() {
+	for c.count > 0 {
+		off := c.tail
+		deleted := c.arena[off+24]&ringDeleted != 0
+		c.pop()
+		if !deleted {
+			c.evict(off)
+			return
+		}
+	}
+}
+
+func (c *RingCache) Remove(key string) bool {
+	off, ok := c.lookup(key)
+	if !ok {
+		return false
+	}
+	c.remove(off, EvictRemoved)
+	return true
+}
+
+func (c *RingCache) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for off := range c.records() {
+		if c.expired(off, now) {
+			c.remove(off, EvictExpired)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *RingCache) Peek(key string) (Value, bool) {
+	off, ok := c.lookup(key)
+	if !ok || c.expired(off, time.Now()) {
+		return nil, false
+	}
+	return c.value(off), true
+}
+
+func (c *RingCache) Contains(key string) bool {
+	off, ok := c.lookup(key)
+	return ok && !c.expired(off, time.Now())
+}
+
+func (c *RingCache) Resize(maxBytes int64) int {
+	n := c.count
+	c.setLimit(maxBytes)
+	for c.count > 0 && c.size > c.limit {
+		c.Evict()
+	}
+	if int64(len(c.arena)) > c.limit {
+		c.compact(int(c.limit))
+	}
+	return n - c.count
+}
+
+func (c *RingCache) Purge() {
+	for off := range c.records() {
+		c.remove(off, EvictRemoved)
+	}
+	c.head, c.tail, c.end, c.wrapped = 0, 0, 0, false
+}
+
+func (c *RingCache) All() iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		now := time.Now()
+		for off := range c.records() {
+			if !c.expired(off, now) && !yield(c.key(off), c.value(off)) {
+				return
+			}
+		}
+	}
+}
+
+func (c *RingCache) Len() int {
+	return c.count
+}
+
+func (c *RingCache) Size() int64 {
+	return c.size
+}
+
+func (c *RingCache) setLimit(maxBytes int64) {
+	c.opts.MaxBytes = maxBytes
+	c.limit = ringMaxArena
+	if maxBytes != 0 {
+		c.limit = min(maxBytes, ringMaxArena)
+	}
+}
+
+// encode builds the record of a pair in c.buf.
+func (c *RingCache) encode(key string, value Value, expire time.Time) ([]byte, error) {
+	rec := append(c.buf[:0], make([]byte, ringHeaderSize)...)
+	rec = append(rec, key...)
+	rec, err := c.codec.AppendValue(rec, value)
+	if err != nil {
+		return nil, err
+	}
+	c.buf = rec
+
+	binary.LittleEndian.PutUint64(rec[0:], maphash.String(c.seed, key))
+	if !expire.IsZero() {
+		binary.LittleEndian.PutUint64(rec[8:], uint64(expire.UnixNano()))
+	}
+	binary.LittleEndian.PutUint32(rec[16:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(rec[20:], uint32(len(rec)-ringHeaderSize-len(key)))
+	rec[24] = 0
+	return rec, nil
+}
+
+// lookup returns the offset of the record of %key.
+func (c *RingCache) lookup(key string) (int, bool) {
+	off, ok := c.index[maphash.String(c.seed, key)]
+	if !ok || string(c.keyBytes(int(off))) != key {
+		return 0, false
+	}
+	return int(off), true
+}
+
+func (c *RingCache) hash(off int) uint64 {
+	return binary.LittleEndian.Uint64(c.arena[off:])
+}
+
+func (c *RingCache) keyLen(off int) int {
+	return int(binary.LittleEndian.Uint32(c.arena[off+16:]))
+}
+
+func (c *RingCache) valueLen(off int) int {
+	return int(binary.LittleEndian.Uint32(c.arena[off+20:]))
+}
+
+// recordSize returns how many bytes the record at %off takes.
+func (c *RingCache) recordSize(off int) int {
+	return ringHeaderSize + c.keyLen(off) + c.valueLen(off)
+}
+
+func (c *RingCache) key(off int) string {
+	return string(c.keyBytes(off))
+}
+
+func (c *RingCache) keyBytes(off int) []byte {
+	start := off + ringHeaderSize
+	return c.arena[start : start+c.keyLen(off)]
+}
+
+func (c *RingCache) value(off int) Value {
+	start := off + ringHeaderSize + c.keyLen(off)
+	value, err := c.codec.DecodeValue(c.arena[start : start+c.valueLen(off)])
+	if err != nil {
+		// the codec wrote it, it should be able to read it.
+		panic(err)
+	}
+	return value
+}
+
+func (c *RingCache) expired(off int, now time.Time) bool {
+	expire := int64(binary.LittleEndian.Uint64(c.arena[off+8:]))
+	return expire != 0 && now.UnixNano() >= expire
+}
+
+// records returns an iterator over the offsets of the records which are not deleted,
+// from the tail to the head. They may be removed during the iteration.
+func (c *RingCache) records() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for off, wrapped := c.tail, c.wrapped; c.count > 0; {
+			if wrapped && off == c.end {
+				off, wrapped = 0, false
+			}
+			if !wrapped && off >= c.head {
+				return
+			}
+			next := off + c.recordSize(off)
+			if c.arena[off+24]&ringDeleted == 0 && !yield(off) {
+				return
+			}
+			off = next
+		}
+	}
+}
+
+// makeRoom returns the offset where a record of %n bytes can be written,
+// growing the arena or evicting records if there is no room for it.
+func (c *RingCache) makeRoom(n int) int {
+	for {
+		if off, ok := c.room(n); ok {
+			return off
+		}
+		if int64(len(c.arena)) < c.limit {
+			c.compact(int(min(max(2*int64(len(c.arena)), int64(n), ringMinArena), c.limit)))
+			continue
+		}
+
+		// the ring is full, the tail goes away.
+		off := c.tail
+		flags := c.arena[off+24]
+		c.pop()
+		switch {
+		case flags&ringDeleted != 0:
+		case flags&ringAccessed != 0:
+			c.arena[off+24] &^= ringAccessed
+			if to, ok := c.room(c.recordSize(off)); ok {
+				c.move(off, to)
+				continue
+			}
+			c.evict(off)
+		default:
+			c.evict(off)
+		}
+	}
+}
+
+// room returns where a record of %n bytes can be written without evicting anything.
+func (c *RingCache) room(n int) (int, bool) {
+	if c.wrapped {
+		return c.head, c.head+n <= c.tail
+	}
+	if c.head+n <= len(c.arena) {
+		return c.head, true
+	}
+	return 0, n <= c.tail
+}
+
+// write copies %rec to %off, which was returned by room, and indexes it.
+func (c *RingCache) write(off int, rec []byte) {
+	if off < c.head {
+		c.end, c.wrapped = c.head, true
+	}
+	copy(c.arena[off:], rec)
+	c.head = off + len(rec)
+	c.index[binary.LittleEndian.Uint64(rec)] = uint32(off)
+	c.count++
+	c.size += int64(len(rec))
+}
+
+// move writes the record at %from, which has just been popped, again at %to.
+func (c *RingCache) move(from, to int) {
+	n := c.recordSize(from)
+	if to < c.head {
+		c.end, c.wrapped = c.head, true
+	}
+	copy(c.arena[to:to+n], c.arena[from:from+n])
+	c.head = to + n
+	c.index[c.hash(to)] = uint32(to)
+}
+
+// pop moves the tail past its record, whose bytes stay readable until something is written.
+func (c *RingCache) pop() {
+	c.tail += c.recordSize(c.tail)
+	if c.wrapped && c.tail == c.end {
+		c.tail, c.wrapped = 0, false
+	}
+	if !c.wrapped && c.tail == c.head {
+		c.head, c.tail = 0, 0
+	}
+}
+
+// evict drops the record at %off, which has just been popped, for lack of room.
+func (c *RingCache) evict(off int) {
+	key, value := c.key(off), c.value(off)
+	c.unlink(off)
+	c.evicted(key, value, EvictCapacity)
+}
+
+// remove drops the record at %off and reports it for %reason.
+func (c *RingCache) remove(off int, reason EvictReason) {
+	key, value := c.key(off), c.value(off)
+	c.unlink(off)
+	c.evicted(key, value, reason)
+}
+
+// unlink marks the record at %off deleted, its bytes are reclaimed when the tail gets past it.
+func (c *RingCache) unlink(off int) {
+	c.arena[off+24] |= ringDeleted
+	delete(c.index, c.hash(off))
+	c.count--
+	c.size -= int64(c.recordSize(off))
+}
+
+// compact copies the records which are not deleted to the start of a new arena of %n bytes,
+// which must be large enough for them.
+func (c *RingCache) compact(n int) {
+	arena, head := make([]byte, n), 0
+	for off := range c.records() {
+		size := c.recordSize(off)
+		copy(arena[head:], c.arena[off:off+size])
+		c.index[c.hash(off)] = uint32(head)
+		head += size
+	}
+	c.arena = arena
+	c.head, c.tail, c.end, c.wrapped = head, 0, 0, false
+}
+
+func (c *RingCache) evicted(key string, value Value, reason EvictReason) {
+	if reason == EvictCapacity && c.opts.OnEvicted != nil {
+		c.opts.OnEvicted(key, value)
+	}
+	if c.opts.OnEvictedWithReason != nil {
+		c.opts.OnEvictedWithReason(key, value, reason)
+	}
+}
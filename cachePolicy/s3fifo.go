@@ -0,0 +1,14 @@
+package cachePolicy
+
+import "geecache-s/cachePolicy/generic"
+
+// S3FIFOCache implements S3-FIFO, see generic.S3FIFO.
+type S3FIFOCache = generic.S3FIFO[string, Value]
+
+func init() {
+	Register(S3FifoPolicy, func(opts Options) Cache { return NewS3FIFOCache(opts) })
+}
+
+func NewS3FIFOCache(opts Options) *S3FIFOCache {
+	return generic.NewS3FIFO(genericOptions(opts, pairSize))
+}
@@ -0,0 +1,14 @@
+package cachePolicy
+
+import "geecache-s/cachePolicy/generic"
+
+// SIEVECache implements SIEVE, see generic.SIEVE.
+type SIEVECache = generic.SIEVE[string, Value]
+
+func init() {
+	Register(SievePolicy, func(opts Options) Cache { return NewSIEVECache(opts) })
+}
+
+func NewSIEVECache(opts Options) *SIEVECache {
+	return generic.NewSIEVE(genericOptions(opts, pairSize))
+}
@@ -0,0 +1,14 @@
+package cachePolicy
+
+import "geecache-s/cachePolicy/generic"
+
+// TinyLFUCache implements W-TinyLFU, see generic.TinyLFU.
+type TinyLFUCache = generic.TinyLFU[string, Value]
+
+func init() {
+	Register(TinyLfuPolicy, func(opts Options) Cache { return NewTinyLFUCache(opts) })
+}
+
+func NewTinyLFUCache(opts Options) *TinyLFUCache {
+	return generic.NewTinyLFU(genericOptions(opts, pairSize))
+}
@@ -1,11 +1,17 @@
 package geecaches
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"geecache-s/cachePolicy"
 	pb "geecache-s/geecachespb"
 	"geecache-s/singleflight"
+	"math/rand"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 //	Main process of GeeCache-s:
@@ -26,6 +32,57 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key)
 }
 
+// A ContextGetter is a Getter which also receives the context of the
+// Group.GetContext call that triggered the load.
+// The context is cancelled once every caller waiting for the key has given up.
+type ContextGetter interface {
+	Getter
+	GetContext(ctx context.Context, key string) ([]byte, error)
+}
+
+type ContextGetterFunc func(ctx context.Context, key string) ([]byte, error)
+
+func (f ContextGetterFunc) Get(key string) ([]byte, error) {
+	return f(context.Background(), key)
+}
+
+func (f ContextGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+// A Result is a value returned by a MetaGetter, along with how it should be cached.
+type Result struct {
+	Value []byte
+
+	// When the value expires.
+	// The zero time falls back to GroupOptions.DefaultTTL, a past time is handled like NoCache.
+	Expire time.Time
+
+	// The version of the value in the backing store, see ByteView.Version.
+	Version int64
+
+	// If true, the value is returned to the caller but not cached.
+	NoCache bool
+}
+
+// A MetaGetter is a Getter which can tell how long each value is valid,
+// which version it is and whether it may be cached at all.
+type MetaGetter interface {
+	Getter
+	GetMeta(ctx context.Context, key string) (Result, error)
+}
+
+type MetaGetterFunc func(ctx context.Context, key string) (Result, error)
+
+func (f MetaGetterFunc) Get(key string) ([]byte, error) {
+	res, err := f(context.Background(), key)
+	return res.Value, err
+}
+
+func (f MetaGetterFunc) GetMeta(ctx context.Context, key string) (Result, error) {
+	return f(ctx, key)
+}
+
 var (
 	groupsMut sync.RWMutex
 	groups    = make(map[string]*Group)
@@ -39,10 +96,15 @@ func GetGroup(name string) *Group {
 }
 
 type GroupOptions struct {
-	// The caching policy of this group
+	// The caching policy of this group, one of the policies registered by cachePolicy.Register.
+	// NewGroupWithOpts panics if it is not registered, unless CacheFactory is set.
 	// Default: LRU
 	CachePolicy cachePolicy.CachePolicy
 
+	// If set, it is used to create the caches of this group instead of CachePolicy.
+	// Default: nil
+	CacheFactory cachePolicy.Factory
+
 	// If the cache does not contain the specified key and that key is managed by the current node,
 	// the Getter function is automatically invoked to fetch its corresponding value.
 	// If the user does not specify this function, the Get() function will return an error when the cache misses
@@ -51,13 +113,92 @@ type GroupOptions struct {
 
 	// The maximum number of bytes that a cache can occupy in memory.
 	// When the value is 0, there is no limit on memory usage.
+	// With a MemoryManager, it is the most the manager may give the group instead.
 	// Default: 0
 	MaxBytes int64
+
+	// If set, the group is registered with it, which then sets the maximum number of bytes
+	// of the group from a budget shared with other groups, see MemoryManager.
+	// Default: nil
+	MemoryManager *MemoryManager
+
+	// The least a MemoryManager may give the group.
+	// Default: 0
+	MinBytes int64
+
+	// The number of independently locked shards each cache of the group is split into,
+	// so that concurrent accesses to different keys rarely wait for the same lock.
+	// MaxBytes is divided evenly among the shards, thus a single entry may not
+	// take more than MaxBytes/Shards bytes. With fewer bytes than shards,
+	// only as many shards as there are bytes hold entries.
+	// Default: 1
+	Shards int
+
+	// If true, cache hits are served from a concurrent map without taking any lock,
+	// and recorded in lossy buffers which are replayed into the caching policy in batches.
+	// It scales reads with the number of cores, at the cost of an extra map per shard
+	// and of a caching policy that may miss a few accesses.
+	// Default: false
+	BufferedReads bool
+
+	// The fraction of MaxBytes set aside for the hot cache,
+	// which keeps a sample of the values loaded from other peers
+	// so that the hottest of them are served without a network round trip.
+	// The main cache gets the rest of MaxBytes, and all of it until peers are registered,
+	// since the hot cache is only filled from them.
+	// When the value is 0, there is no hot cache.
+	// Default: 0.125
+	HotCacheRatio float64
+
+	// One of every HotCacheSampling values loaded from other peers is kept in the hot cache.
+	// When the value is 0, no value is kept, as if HotCacheRatio was 0.
+	// Default: 10
+	HotCacheSampling int
+
+	// How often the LFU policy halves the access counts of the entries,
+	// so that keys which were hot long ago do not stay in the cache forever.
+	// When the value is 0, counts never decay.
+	// Default: 0
+	DecayPeriod time.Duration
+
+	// How long an entry stays valid after it is added or loaded,
+	// unless a TTL is given explicitly, e.g. by AddWithTTL.
+	// When the value is 0, entries never expire.
+	// Default: 0
+	DefaultTTL time.Duration
+
+	// How often expired entries are swept out of the cache in the background.
+	// Expired entries are always dropped lazily when they are read,
+	// the sweeper only reclaims the memory of those never read again.
+	// When the value is 0, there is no background sweeping.
+	// Default: 1 minute
+	SweepInterval time.Duration
+
+	// The number of bytes counted for each entry on top of its key and value,
+	// so that MaxBytes bounds the memory the caches really take on the heap.
+	// cachePolicy.EntryOverhead is an estimate for the built-in policies.
+	// Default: 0
+	EntryOverhead int64
+
+	// The fraction of the bytes of a cache its pinned entries may take at most, see AddPinned.
+	// When the value is 0, nothing can be pinned, unless MaxBytes is 0.
+	// Default: 0.5
+	MaxPinnedRatio float64
+
+	// Called whenever an entry leaves the main or the hot cache, or its value is replaced,
+	// along with the reason. It is called with the cache locked, so it must not use the group.
+	// Default: nil
+	OnEvicted func(key string, value ByteView, reason cachePolicy.EvictReason)
 }
 
 func NewGroupOptions() *GroupOptions {
 	return &GroupOptions{
-		CachePolicy: cachePolicy.LruPolicy,
+		CachePolicy:      cachePolicy.LruPolicy,
+		Shards:           1,
+		HotCacheRatio:    0.125,
+		HotCacheSampling: 10,
+		SweepInterval:    time.Minute,
+		MaxPinnedRatio:   0.5,
 	}
 }
 
@@ -66,52 +207,86 @@ func NewGroupOptions() *GroupOptions {
 type Group struct {
 	name string
 
-	getter    Getter
-	mainCache cache
+	getter Getter
+
+	// mainCache holds the keys this peer owns, or added through it.
+	mainCache *cache
+	// hotCache holds a sample of the keys owned by other peers.
+	hotCache         *cache
+	hotCacheRatio    float64
+	hotCacheSampling int
+
+	// guards the updates of maxBytes, see SetMaxBytes, and the registration of peersPicker.
+	maxBytesMut sync.Mutex
+	maxBytes    atomic.Int64
 
 	peersPicker PeerPicker
 
 	loader *singleflight.Group
-}
 
-func NewGroup(name string, maxBytes int64, getter Getter, policy cachePolicy.CachePolicy) *Group {
-	if g, ok := groups[name]; ok {
-		return g
-	}
-	g := &Group{
-		name:   name,
-		getter: getter,
-		mainCache: cache{
-			maxBytes: maxBytes,
-			policy:   policy,
-		},
-		loader: &singleflight.Group{},
-	}
+	stats groupStats
 
-	groupsMut.Lock()
-	groups[name] = g
-	groupsMut.Unlock()
+	defaultTTL    time.Duration
+	sweepInterval time.Duration
+	sweepOnce     sync.Once
+}
 
-	return g
+func NewGroup(name string, maxBytes int64, getter Getter, policy cachePolicy.CachePolicy) *Group {
+	opts := NewGroupOptions()
+	opts.MaxBytes = maxBytes
+	opts.Getter = getter
+	opts.CachePolicy = policy
+	return NewGroupWithOpts(name, opts)
 }
 
 func NewGroupWithOpts(name string, opts *GroupOptions) *Group {
+	groupsMut.Lock()
+	defer groupsMut.Unlock()
+
 	if g, ok := groups[name]; ok {
 		return g
 	}
-	g := &Group{
-		name:   name,
-		getter: opts.Getter,
-		mainCache: cache{
-			maxBytes: opts.MaxBytes,
-			policy:   opts.CachePolicy,
-		},
-		loader: &singleflight.Group{},
+	if opts.CacheFactory == nil && !slices.Contains(cachePolicy.Policies(), opts.CachePolicy) {
+		// caught here, as the caches are only created at the first insertion.
+		panic(fmt.Sprintf("geecache: unknown cache policy %q for group %s", opts.CachePolicy, name))
+	}
+	hotCacheRatio, hotCacheSampling := 0.0, 0
+	if opts.HotCacheRatio > 0 {
+		hotCacheRatio = opts.HotCacheRatio
+		hotCacheSampling = opts.HotCacheSampling
 	}
+	// no peers yet, the main cache gets all of MaxBytes.
+	mainBytes, hotBytes := splitMaxBytes(opts.MaxBytes, 0)
+	mainOpts := cacheOptions{
+		policy:        opts.CachePolicy,
+		factory:       opts.CacheFactory,
+		maxBytes:      mainBytes,
+		shards:        opts.Shards,
+		decayPeriod:   opts.DecayPeriod,
+		bufferedReads: opts.BufferedReads,
+		onEvicted:     opts.OnEvicted,
+		entryOverhead: opts.EntryOverhead,
+		pinnedRatio:   opts.MaxPinnedRatio,
+	}
+	hotOpts := mainOpts
+	hotOpts.maxBytes = hotBytes
 
-	groupsMut.Lock()
+	g := &Group{
+		name:             name,
+		getter:           opts.Getter,
+		mainCache:        newCache(mainOpts),
+		hotCache:         newCache(hotOpts),
+		hotCacheRatio:    hotCacheRatio,
+		hotCacheSampling: hotCacheSampling,
+		loader:           &singleflight.Group{},
+		defaultTTL:       opts.DefaultTTL,
+		sweepInterval:    opts.SweepInterval,
+	}
+	g.maxBytes.Store(opts.MaxBytes)
 	groups[name] = g
-	groupsMut.Unlock()
+	if opts.MemoryManager != nil {
+		opts.MemoryManager.Register(g, opts.MinBytes, opts.MaxBytes)
+	}
 
 	return g
 }
@@ -120,78 +295,307 @@ func (g *Group) Name() string {
 	return g.name
 }
 
+// MaxBytes returns the maximum number of bytes of the group, see SetMaxBytes.
+func (g *Group) MaxBytes() int64 {
+	return g.maxBytes.Load()
+}
+
+// SetMaxBytes changes the maximum number of bytes of the group on the current peer,
+// which is split between the main and the hot cache like GroupOptions.MaxBytes,
+// once peers are registered.
+// A larger limit applies at once, while the entries which do not fit in a smaller one
+// are evicted in the background. When n is 0, there is no limit.
+// The limit of a group registered with a MemoryManager is overridden at its next rebalance.
+func (g *Group) SetMaxBytes(n int64) {
+	g.maxBytesMut.Lock()
+	defer g.maxBytesMut.Unlock()
+	g.setMaxBytesLocked(n)
+}
+
+// setMaxBytesLocked is SetMaxBytes with maxBytesMut held.
+func (g *Group) setMaxBytesLocked(n int64) {
+	g.maxBytes.Store(n)
+	hotCacheRatio := g.hotCacheRatio
+	if g.peersPicker == nil {
+		hotCacheRatio = 0
+	}
+	mainBytes, hotBytes := splitMaxBytes(n, hotCacheRatio)
+	g.mainCache.setMaxBytes(mainBytes)
+	g.hotCache.setMaxBytes(hotBytes)
+}
+
+// splitMaxBytes divides maxBytes between the main and the hot cache.
+// Each of them gets at least 1 byte of a limited maxBytes, as 0 would mean no limit at all.
+func splitMaxBytes(maxBytes int64, hotCacheRatio float64) (mainBytes, hotBytes int64) {
+	hotBytes = int64(float64(maxBytes) * hotCacheRatio)
+	if maxBytes == 0 || hotCacheRatio <= 0 {
+		return maxBytes - hotBytes, hotBytes
+	}
+	hotBytes = max(hotBytes, 1)
+	return max(maxBytes-hotBytes, 1), hotBytes
+}
+
 func (g *Group) RegisterPeers(peersPicker PeerPicker) {
+	g.maxBytesMut.Lock()
+	defer g.maxBytesMut.Unlock()
 	if g.peersPicker != nil {
 		panic("registerPeerPicker called more than once")
 	}
 	g.peersPicker = peersPicker
+	// the hot cache takes its share of MaxBytes from now on.
+	if g.hotCacheRatio > 0 {
+		g.setMaxBytesLocked(g.maxBytes.Load())
+	}
 }
 
 func (g *Group) Get(key string) (ByteView, error) {
+	return g.GetContext(context.Background(), key)
+}
+
+// GetContext is like Get, but gives up as soon as ctx is done.
+// A load shared with other callers keeps going for them.
+func (g *Group) GetContext(ctx context.Context, key string) (ByteView, error) {
 	if value, ok := g.mainCache.get(key); ok {
 		return value, nil
 	}
+	if value, ok := g.hotCache.get(key); ok {
+		return value, nil
+	}
 
-	return g.load(key)
+	g.stats.loads.Add(1)
+	return g.load(ctx, key)
 }
 
-func (g *Group) load(key string) (ByteView, error) {
+func (g *Group) load(ctx context.Context, key string) (ByteView, error) {
 	// each key is only fetched once (either locally or remotely)
 	// regardless of the number of concurrent callers.
-	bytes, err := g.loader.Do(key, func() (any, error) {
+	bytes, err := g.loader.DoContext(ctx, key, func(ctx context.Context) (any, error) {
+		g.stats.loadsDeduped.Add(1)
 		if g.peersPicker != nil {
 			peerGetter, ok := g.peersPicker.PickPeer(key)
 			if ok {
-				return g.loadRemotely(key, peerGetter)
+				value, err := g.loadRemotely(ctx, key, peerGetter)
+				if err != nil {
+					g.stats.peerErrors.Add(1)
+				} else {
+					g.stats.peerLoads.Add(1)
+				}
+				return value, err
 			}
 		}
 
-		return g.loadLocally(key)
+		value, err := g.loadLocally(ctx, key)
+		if err != nil {
+			g.stats.localLoadErrs.Add(1)
+		} else {
+			g.stats.localLoads.Add(1)
+		}
+		return value, err
 	})
-	return bytes.(ByteView), err
+	if err != nil {
+		return ByteView{}, err
+	}
+	return bytes.(ByteView), nil
 }
 
-func (g *Group) loadLocally(key string) (ByteView, error) {
-	if g.getter == nil {
-		return ByteView{}, fmt.Errorf("no getter specified, unable to retrieve data")
-	}
-	value, err := g.getter.Get(key)
+func (g *Group) loadLocally(ctx context.Context, key string) (ByteView, error) {
+	start := time.Now()
+	res, err := g.getFromGetter(ctx, key)
+	// how long the value would take to load again, for the policies which weigh it.
+	loadCost := time.Since(start)
 	if err != nil {
 		return ByteView{}, err
 	}
 
-	v := ByteView{value}
-	g.mainCache.add(key, v)
+	v := ByteView{Bytes: res.Value, version: res.Version}
+	if res.NoCache {
+		v.noCache = true
+		return v, nil
+	}
+
+	v.expire = res.Expire
+	if v.expire.IsZero() {
+		v.expire = g.expireAt(g.defaultTTL)
+	}
+	if v.expiredNow() {
+		// it could only evict live entries before being dropped itself.
+		v.noCache = true
+		return v, nil
+	}
+	g.populateCache(g.mainCache, key, v, v.expire, loadCost)
 	return v, nil
 }
 
-func (g *Group) loadRemotely(key string, peer PeerHandler) (ByteView, error) {
+func (g *Group) getFromGetter(ctx context.Context, key string) (Result, error) {
+	switch getter := g.getter.(type) {
+	case nil:
+		return Result{}, fmt.Errorf("no getter specified, unable to retrieve data")
+	case MetaGetter:
+		return getter.GetMeta(ctx, key)
+	case ContextGetter:
+		value, err := getter.GetContext(ctx, key)
+		return Result{Value: value}, err
+	default:
+		value, err := getter.Get(key)
+		return Result{Value: value}, err
+	}
+}
+
+func (g *Group) loadRemotely(ctx context.Context, key string, peer PeerHandler) (ByteView, error) {
 	in := &pb.GetRequest{
 		Group: g.Name(),
 		Key:   key,
 	}
 	out := &pb.GetResponse{}
-	err := peer.Get(in, out)
+	err := peer.Get(ctx, in, out)
 	if err != nil {
 		return ByteView{}, err
 	}
-	return ByteView{out.Value}, nil
+
+	v := ByteView{Bytes: out.Value, version: out.Version, noCache: out.NoCache}
+	if out.Expire != 0 {
+		v.expire = time.Unix(0, out.Expire)
+	}
+	if g.shouldHotCache(v) {
+		g.populateCache(g.hotCache, key, v, v.expire, 0)
+	}
+	return v, nil
+}
+
+func (g *Group) shouldHotCache(v ByteView) bool {
+	if v.noCache || g.hotCacheSampling <= 0 {
+		return false
+	}
+	return g.hotCacheSampling == 1 || rand.Intn(g.hotCacheSampling) == 0
 }
 
+// Add stores value under key on the peer which owns it and on the current one.
+// The entry expires after GroupOptions.DefaultTTL.
 func (g *Group) Add(key string, value ByteView) error {
-	if peer, ok := g.peersPicker.PickPeer(key); ok {
-		in := &pb.AddRequest{
-			Group: g.name,
-			Key:   key,
-			Value: value.Bytes,
+	return g.add(key, value, g.expireAt(g.defaultTTL))
+}
+
+// AddWithTTL is like Add, but the entry expires after ttl.
+// A ttl of 0 means the entry never expires.
+func (g *Group) AddWithTTL(key string, value ByteView, ttl time.Duration) error {
+	return g.add(key, value, g.expireAt(ttl))
+}
+
+// AddPinned stores value under key on the current peer, where it is neither evicted,
+// even under memory pressure, nor expires, until it is unpinned or removed.
+// Pinned entries are kept out of the caching policy, and their bytes are taken
+// from MaxBytes, of which they may not take more than GroupOptions.MaxPinnedRatio.
+// Adding key again, pinned or not, updates the pinned value.
+// An entry already cached under key is reported as removed to GroupOptions.OnEvicted.
+func (g *Group) AddPinned(key string, value ByteView) error {
+	return g.mainCache.pin(key, value)
+}
+
+// Unpin makes the entry of key on the current peer a regular one again, which may be evicted,
+// and returns false if it is not pinned. If the caching policy cannot take the entry,
+// e.g. because MaxBytes was lowered since it was pinned, it stays pinned and the error is returned.
+func (g *Group) Unpin(key string) (bool, error) {
+	return g.mainCache.unpin(key)
+}
+
+func (g *Group) add(key string, value ByteView, expire time.Time) error {
+	if g.peersPicker != nil {
+		if peer, ok := g.peersPicker.PickPeer(key); ok {
+			in := &pb.AddRequest{
+				Group: g.name,
+				Key:   key,
+				Value: value.Bytes,
+			}
+			if !expire.IsZero() {
+				in.Expire = expire.UnixNano()
+			}
+			empty := &pb.Empty{}
+			// add remotely
+			if err := peer.Add(in, empty); err != nil {
+				return err
+			}
 		}
-		empty := &pb.Empty{}
-		// add remotely
-		if err := peer.Add(in, empty); err != nil {
+	}
+
+	// add locally
+	return g.populateCache(g.mainCache, key, value, expire, 0)
+}
+
+// populateCache stores a pair which took %loadCost to load, 0 if it is unknown.
+func (g *Group) populateCache(c *cache, key string, value ByteView, expire time.Time, loadCost time.Duration) error {
+	value.expire = expire
+	if !expire.IsZero() && g.sweepInterval > 0 {
+		g.sweepOnce.Do(func() { go g.sweep() })
+	}
+	return c.add(key, value, expire, loadCost)
+}
+
+// sweep periodically drops the expired entries nobody reads anymore.
+func (g *Group) sweep() {
+	ticker := time.NewTicker(g.sweepInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		g.mainCache.removeExpired()
+		g.hotCache.removeExpired()
+	}
+}
+
+// expireAt converts ttl to an absolute expiration time, the zero time if ttl is 0.
+func (g *Group) expireAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// Remove evicts key from the peer which owns it, then from every other peer
+// that may hold a copy, including the current one.
+func (g *Group) Remove(key string) error {
+	if g.peersPicker == nil {
+		g.localRemove(key)
+		return nil
+	}
+
+	in := &pb.RemoveRequest{
+		Group: g.name,
+		Key:   key,
+	}
+
+	// remove from the owner first, so that no other peer can
+	// fetch the old value from it again.
+	owner, ok := g.peersPicker.PickPeer(key)
+	if ok {
+		if err := owner.Remove(in, &pb.Empty{}); err != nil {
 			return err
 		}
 	}
 
-	// add locally
-	return g.mainCache.add(key, value)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, peer := range g.peersPicker.GetAll() {
+		if ok && peer == owner {
+			continue
+		}
+		wg.Add(1)
+		go func(peer PeerHandler) {
+			defer wg.Done()
+			if err := peer.Remove(in, &pb.Empty{}); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	g.localRemove(key)
+	return errors.Join(errs...)
+}
+
+func (g *Group) localRemove(key string) {
+	g.mainCache.remove(key)
+	g.hotCache.remove(key)
 }
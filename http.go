@@ -2,6 +2,7 @@ package geecaches
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"geecache-s/consistenthash"
 	pb "geecache-s/geecachespb"
@@ -9,8 +10,10 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 )
@@ -95,25 +98,27 @@ func (p *HttpPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == "GET" {
-		// /<basepath>/<groupname>/<key> required
-		strs := strings.SplitN(r.URL.Path[len(p.opts.BasePath):], "/", 2)
-		if len(strs) != 2 {
-			http.Error(w, "bad request", http.StatusBadRequest)
+		g, key, ok := p.parseGroupKey(w, r)
+		if !ok {
 			return
 		}
 
-		g := GetGroup(strs[0])
-		if g == nil {
-			http.Error(w, "no such group: "+strs[0], http.StatusNotFound)
-		}
-
-		view, err := g.Get(strs[1])
+		g.stats.serverRequests.Add(1)
+		view, err := g.GetContext(r.Context(), key)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		body, err := proto.Marshal(&pb.GetResponse{Value: view.ByteSlice()})
+		out := &pb.GetResponse{
+			Value:   view.ByteSlice(),
+			Version: view.Version(),
+			NoCache: view.noCache,
+		}
+		if !view.Expire().IsZero() {
+			out.Expire = view.Expire().UnixNano()
+		}
+		body, err := proto.Marshal(out)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -134,12 +139,72 @@ func (p *HttpPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		g := GetGroup(req.Group)
+		if g == nil {
+			http.Error(w, "no such group: "+req.Group, http.StatusNotFound)
+			return
+		}
 
-		err = g.Add(req.Key, ByteView{req.Value})
+		var expire time.Time
+		if req.Expire != 0 {
+			expire = time.Unix(0, req.Expire)
+		}
+		err = g.add(req.Key, ByteView{Bytes: req.Value}, expire)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
+	} else if r.Method == "DELETE" {
+		g, key, ok := p.parseGroupKey(w, r)
+		if !ok {
+			return
+		}
+
+		// the peer which sends the request takes care of the others.
+		g.localRemove(key)
+	} else if r.Method == "PUT" {
+		// admin: PUT /<basepath>/<groupname>?maxBytes=<n> resizes the group on this peer.
+		g, ok := p.parseGroup(w, r)
+		if !ok {
+			return
+		}
+
+		maxBytes, err := strconv.ParseInt(r.URL.Query().Get("maxBytes"), 10, 64)
+		if err != nil || maxBytes < 0 {
+			http.Error(w, "maxBytes must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		g.SetMaxBytes(maxBytes)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// parseGroup parses /<basepath>/<groupname> and looks up the group.
+// It replies with an error and returns false if the group does not exist.
+func (p *HttpPool) parseGroup(w http.ResponseWriter, r *http.Request) (*Group, bool) {
+	name := strings.Trim(r.URL.Path[len(p.opts.BasePath):], "/")
+	g := GetGroup(name)
+	if g == nil {
+		http.Error(w, "no such group: "+name, http.StatusNotFound)
+		return nil, false
 	}
+	return g, true
+}
+
+// parseGroupKey parses /<basepath>/<groupname>/<key> and looks up the group.
+// It replies with an error and returns false if the path is malformed or the group does not exist.
+func (p *HttpPool) parseGroupKey(w http.ResponseWriter, r *http.Request) (*Group, string, bool) {
+	path := strings.TrimPrefix(r.URL.Path[len(p.opts.BasePath):], "/")
+	strs := strings.SplitN(path, "/", 2)
+	if len(strs) != 2 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return nil, "", false
+	}
+
+	g := GetGroup(strs[0])
+	if g == nil {
+		http.Error(w, "no such group: "+strs[0], http.StatusNotFound)
+		return nil, "", false
+	}
+	return g, strs[1], true
 }
 
 // Set updates the pool's list of peers.
@@ -172,6 +237,19 @@ func (p *HttpPool) PickPeer(key string) (PeerHandler, bool) {
 	}
 }
 
+func (p *HttpPool) GetAll() []PeerHandler {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	handlers := make([]PeerHandler, 0, len(p.httpHandlers))
+	for peer, handler := range p.httpHandlers {
+		if peer != p.self {
+			handlers = append(handlers, handler)
+		}
+	}
+	return handlers
+}
+
 func (p *HttpPool) SelfAddr() string {
 	return p.self
 }
@@ -183,7 +261,7 @@ type httpHandler struct {
 }
 
 // remote Get
-func (g *httpHandler) Get(in *pb.GetRequest, out *pb.GetResponse) error {
+func (g *httpHandler) Get(ctx context.Context, in *pb.GetRequest, out *pb.GetResponse) error {
 	url := fmt.Sprintf(
 		"%v/%v/%v",
 		g.basePath,
@@ -191,8 +269,11 @@ func (g *httpHandler) Get(in *pb.GetRequest, out *pb.GetResponse) error {
 		in.GetKey(),
 	)
 
-	resp, err := http.Get(url)
-
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -202,14 +283,10 @@ func (g *httpHandler) Get(in *pb.GetRequest, out *pb.GetResponse) error {
 	}
 
 	bytes, err := io.ReadAll(resp.Body)
-	if err := proto.Unmarshal(bytes, out); err != nil {
-		return nil
-	}
-
 	if err != nil {
 		return err
 	}
-	return nil
+	return proto.Unmarshal(bytes, out)
 }
 
 // remote Add
@@ -232,4 +309,28 @@ func (g *httpHandler) Add(in *pb.AddRequest, out *pb.Empty) error {
 	return nil
 }
 
+// remote Remove
+func (g *httpHandler) Remove(in *pb.RemoveRequest, out *pb.Empty) error {
+	url := fmt.Sprintf(
+		"%v/%v/%v",
+		g.basePath,
+		in.GetGroup(),
+		in.GetKey(),
+	)
+
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("peer[%s] return %d", g.basePath, resp.StatusCode)
+	}
+	return nil
+}
+
 var _ PeerHandler = (*httpHandler)(nil)
@@ -0,0 +1,261 @@
+package geecaches
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// A MemoryManager shares one memory budget among the groups registered with it,
+// instead of each of them having its own fixed MaxBytes.
+//
+// Every rebalance, each group first gets its minimum. A group which did not evict
+// anything to make room for new entries since the last rebalance is then given the bytes
+// it uses plus some headroom to grow into, so that the memory it does not need goes back
+// to the others. The evictions made to fit in a smaller limit do not count.
+// The rest of the budget is shared among the groups which did evict, in proportion to
+// their misses, i.e. to their demand times their miss rate, up to their maximum.
+// When there is not enough for everybody, the groups are scaled down alike.
+type MemoryManager struct {
+	mut      sync.Mutex
+	maxBytes int64
+	groups   []*managedGroup
+
+	interval time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+	loopOnce sync.Once
+}
+
+// managedGroup is a group registered with a MemoryManager,
+// along with its statistics at the last rebalance.
+type managedGroup struct {
+	group              *Group
+	minBytes, maxBytes int64 // maxBytes is 0 when there is no maximum
+
+	gets, hits int64
+	// the evictions of the group besides those made to fit in the limits the manager set.
+	evictions int64
+}
+
+// NewMemoryManager returns a manager sharing maxBytes among its groups,
+// which rebalances them every interval once a group is registered.
+// When interval is 0, the groups are only rebalanced when Rebalance is called,
+// and when they are registered or unregistered.
+func NewMemoryManager(maxBytes int64, interval time.Duration) *MemoryManager {
+	if maxBytes <= 0 {
+		panic("memory manager needs a positive budget")
+	}
+	return &MemoryManager{
+		maxBytes: maxBytes,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// MaxBytes returns the budget shared by the groups.
+func (m *MemoryManager) MaxBytes() int64 {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return m.maxBytes
+}
+
+// SetMaxBytes changes the budget shared by the groups and rebalances them at once,
+// e.g. to take memory back from them under pressure.
+func (m *MemoryManager) SetMaxBytes(maxBytes int64) {
+	if maxBytes <= 0 {
+		panic("memory manager needs a positive budget")
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.maxBytes = maxBytes
+	m.rebalance()
+}
+
+// Register lets the manager set the maximum number of bytes of %g,
+// which is never given less than %minBytes nor more than %maxBytes.
+// When maxBytes is 0, the group may get the whole budget.
+// Registering a group again only changes its limits.
+func (m *MemoryManager) Register(g *Group, minBytes, maxBytes int64) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if maxBytes != 0 {
+		minBytes = min(minBytes, maxBytes)
+	}
+	mg := m.lookup(g)
+	if mg == nil {
+		stats := g.Stats()
+		mg = &managedGroup{
+			group:     g,
+			gets:      stats.Gets,
+			hits:      stats.CacheHits,
+			evictions: g.demandEvictions(),
+		}
+		m.groups = append(m.groups, mg)
+	}
+	mg.minBytes, mg.maxBytes = max(minBytes, 0), max(maxBytes, 0)
+	m.rebalance()
+
+	if m.interval > 0 {
+		m.loopOnce.Do(func() { go m.loop() })
+	}
+}
+
+// Unregister gives the memory of %g back to the other groups.
+// The group keeps its current maximum number of bytes.
+func (m *MemoryManager) Unregister(g *Group) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for i, mg := range m.groups {
+		if mg.group == g {
+			m.groups = append(m.groups[:i], m.groups[i+1:]...)
+			m.rebalance()
+			return
+		}
+	}
+}
+
+// Rebalance shares the budget among the groups according to their usage since the last one.
+func (m *MemoryManager) Rebalance() {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.rebalance()
+}
+
+// Close stops the periodic rebalancing. The groups keep their current limits.
+func (m *MemoryManager) Close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+func (m *MemoryManager) loop() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Rebalance()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+func (m *MemoryManager) lookup(g *Group) *managedGroup {
+	for _, mg := range m.groups {
+		if mg.group == g {
+			return mg
+		}
+	}
+	return nil
+}
+
+func (m *MemoryManager) rebalance() {
+	n := int64(len(m.groups))
+	if n == 0 {
+		return
+	}
+
+	// a group which does not use its memory gets some headroom anyway,
+	// so that it can fill it up and start evicting if it needs more.
+	minHeadroom := m.maxBytes / (8 * n)
+	alloc := make([]int64, n)
+	target := make([]int64, n)
+	weight := make([]float64, n)
+	var pressed []int
+	for i, mg := range m.groups {
+		stats := mg.group.Stats()
+		used := stats.MainCache.Bytes + stats.HotCache.Bytes
+		evictions := mg.group.demandEvictions()
+		gets, hits := stats.Gets-mg.gets, stats.CacheHits-mg.hits
+		evicted := evictions > mg.evictions
+		mg.gets, mg.hits, mg.evictions = stats.Gets, stats.CacheHits, evictions
+
+		alloc[i] = mg.minBytes
+		if evicted {
+			target[i] = mg.maxBytes
+			weight[i] = float64(gets-hits) + 1
+			pressed = append(pressed, i)
+		} else {
+			target[i] = max(used+max(used/8, minHeadroom), mg.minBytes)
+			if mg.maxBytes != 0 {
+				target[i] = min(target[i], mg.maxBytes)
+			}
+		}
+	}
+
+	// the minimums first, then the groups which have enough, then those which do not.
+	remaining := m.maxBytes - sumBytes(alloc)
+	if remaining < 0 {
+		scaleBytes(alloc, m.maxBytes)
+		remaining = 0
+	}
+	var satisfied []int
+	var wants int64
+	for i := range m.groups {
+		if target[i] != 0 && !slices.Contains(pressed, i) {
+			satisfied = append(satisfied, i)
+			wants += target[i] - alloc[i]
+		}
+	}
+	for _, i := range satisfied {
+		give := target[i] - alloc[i]
+		if wants > remaining {
+			give = int64(float64(give) * float64(remaining) / float64(wants))
+		}
+		alloc[i] += give
+	}
+	remaining = m.maxBytes - sumBytes(alloc)
+	shareBytes(alloc, target, weight, pressed, remaining)
+
+	for i, mg := range m.groups {
+		// 0 would mean no limit at all.
+		mg.group.SetMaxBytes(max(alloc[i], 1))
+	}
+}
+
+// shareBytes hands out %remaining bytes among the groups %pressed in proportion to their weight,
+// without giving any of them more than its target, unless the target is 0.
+func shareBytes(alloc, target []int64, weight []float64, pressed []int, remaining int64) {
+	for remaining > 0 && len(pressed) > 0 {
+		var total float64
+		for _, i := range pressed {
+			total += weight[i]
+		}
+
+		var given int64
+		var next []int
+		for _, i := range pressed {
+			give := int64(float64(remaining) * weight[i] / total)
+			if target[i] != 0 && alloc[i]+give >= target[i] {
+				give = max(target[i]-alloc[i], 0)
+			} else {
+				next = append(next, i)
+			}
+			alloc[i] += give
+			given += give
+		}
+		remaining -= given
+		if len(next) == len(pressed) || given == 0 {
+			// nobody reached its target, the rest is only rounding.
+			return
+		}
+		pressed = next
+	}
+}
+
+// scaleBytes reduces %alloc proportionally so that it sums to at most %total.
+func scaleBytes(alloc []int64, total int64) {
+	all := sumBytes(alloc)
+	for i := range alloc {
+		alloc[i] = int64(float64(alloc[i]) * float64(total) / float64(all))
+	}
+}
+
+func sumBytes(values []int64) int64 {
+	var s int64
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
@@ -1,13 +1,19 @@
 package geecaches
 
-import pb "geecache-s/geecachespb"
+import (
+	"context"
+	pb "geecache-s/geecachespb"
+)
 
 type PeerPicker interface {
 	PickPeer(key string) (PeerHandler, bool)
+	// GetAll returns the handlers of all peers except the current one.
+	GetAll() []PeerHandler
 	SelfAddr() string
 }
 
 type PeerHandler interface {
-	Get(in *pb.GetRequest, out *pb.GetResponse) error
+	Get(ctx context.Context, in *pb.GetRequest, out *pb.GetResponse) error
 	Add(in *pb.AddRequest, out *pb.Empty) error
+	Remove(in *pb.RemoveRequest, out *pb.Empty) error
 }
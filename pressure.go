@@ -0,0 +1,133 @@
+package geecaches
+
+import (
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+const (
+	heapLiveMetric = "/gc/heap/live:bytes"
+	gcCyclesMetric = "/gc/cycles/total:gc-cycles"
+)
+
+type HeapPressureOptions struct {
+	// How often the live heap is compared to the memory limit of the process.
+	// Default: 1 second
+	Interval time.Duration
+
+	// The fraction of the memory limit above which the caches start evicting.
+	// Default: 0.9
+	HighWater float64
+
+	// The fraction of the memory limit the caches evict down to.
+	// Default: 0.8
+	LowWater float64
+}
+
+func NewHeapPressureOptions() *HeapPressureOptions {
+	return &HeapPressureOptions{
+		Interval:  time.Second,
+		HighWater: 0.9,
+		LowWater:  0.8,
+	}
+}
+
+// WatchHeapPressure evicts entries from all groups whenever the live heap gets close to
+// the memory limit of the process, set by debug.SetMemoryLimit or GOMEMLIMIT,
+// so that the garbage collector is not left thrashing before the process runs out of memory.
+// The excess is taken from the groups in proportion to the bytes they hold, and their
+// MaxBytes is left untouched, so that they grow back once the pressure is gone.
+// Nothing is evicted while there is no memory limit.
+//
+// Since MaxBytes only counts the keys and values, see GroupOptions.EntryOverhead
+// to make it account for the memory the caches take as well.
+// It returns a function which stops watching.
+func WatchHeapPressure(opts *HeapPressureOptions) (stop func()) {
+	w := &heapWatcher{
+		opts: *opts,
+		samples: []metrics.Sample{
+			{Name: heapLiveMetric},
+			{Name: gcCyclesMetric},
+		},
+		stop: make(chan struct{}),
+	}
+	go w.watch()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(w.stop) }) }
+}
+
+type heapWatcher struct {
+	opts    HeapPressureOptions
+	samples []metrics.Sample
+	stop    chan struct{}
+
+	// the GC cycle after which the last eviction happened.
+	lastCycle uint64
+}
+
+func (w *heapWatcher) watch() {
+	interval := w.opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *heapWatcher) check() {
+	// a negative input only reads the limit.
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return
+	}
+
+	metrics.Read(w.samples)
+	live, cycle := int64(w.samples[0].Value.Uint64()), w.samples[1].Value.Uint64()
+	// the live heap is only measured by a GC, so what was evicted after
+	// the last one is not accounted for yet.
+	if cycle == w.lastCycle || float64(live) < w.opts.HighWater*float64(limit) {
+		return
+	}
+
+	w.lastCycle = cycle
+	evictAll(live - int64(w.opts.LowWater*float64(limit)))
+}
+
+// evictAll evicts about %n bytes from all groups, in proportion to the bytes they may evict,
+// which leaves out the pinned entries.
+func evictAll(n int64) {
+	groupsMut.RLock()
+	caches := make([]*cache, 0, 2*len(groups))
+	for _, g := range groups {
+		caches = append(caches, g.mainCache, g.hotCache)
+	}
+	groupsMut.RUnlock()
+
+	sizes := make([]int64, len(caches))
+	var total int64
+	for i, c := range caches {
+		stats := c.stats()
+		sizes[i] = stats.Bytes - stats.PinnedBytes
+		total += sizes[i]
+	}
+	if total == 0 {
+		return
+	}
+	for i, c := range caches {
+		if share := int64(float64(n) * float64(sizes[i]) / float64(total)); share > 0 {
+			c.evictBytes(share)
+		}
+	}
+}
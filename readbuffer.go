@@ -0,0 +1,40 @@
+package geecaches
+
+import "sync"
+
+// Number of accesses a stripe of a readBuffer holds before it is drained.
+const readStripeSize = 64
+
+// readBuffer records the keys read from a cacheShard without taking its lock.
+// Keys are appended to per-processor stripes, and a full stripe is handed
+// to drain, which replays the accesses into the cache policy in a batch.
+// It is lossy: a stripe may be dropped when the shard is busy or by the GC,
+// which only makes the policy a little less accurate.
+type readBuffer struct {
+	stripes sync.Pool
+	drain   func(keys []string)
+}
+
+type readStripe struct {
+	keys []string
+}
+
+func newReadBuffer(drain func(keys []string)) *readBuffer {
+	return &readBuffer{
+		stripes: sync.Pool{
+			New: func() any { return &readStripe{keys: make([]string, 0, readStripeSize)} },
+		},
+		drain: drain,
+	}
+}
+
+func (b *readBuffer) push(key string) {
+	s := b.stripes.Get().(*readStripe)
+	s.keys = append(s.keys, key)
+	if len(s.keys) >= readStripeSize {
+		b.drain(s.keys)
+		clear(s.keys)
+		s.keys = s.keys[:0]
+	}
+	b.stripes.Put(s)
+}
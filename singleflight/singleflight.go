@@ -1,13 +1,28 @@
 package singleflight
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
 
 // Package singleflight provides a duplicate function call suppression
 // mechanism.
 type call struct {
-	wg  sync.WaitGroup
-	val interface{}
-	err error
+	done chan struct{} // closed once fn has returned
+	val  interface{}
+	err  error
+
+	// set if fn panicked, the panic is raised again in every waiting caller.
+	panicked *panicError
+
+	// waiters counts the callers still waiting for this call. When all of
+	// them have given up, cancel aborts the context passed to fn.
+	// Both are guarded by Group.mu.
+	waiters int
+	cancel  context.CancelFunc
 }
 
 type Group struct {
@@ -20,28 +35,96 @@ type Group struct {
 // time. If a duplicate comes in, the duplicate caller waits for the
 // original to complete and receives the same results.
 func (g *Group) Do(key string, fn func() (any, error)) (any, error) {
+	return g.DoContext(context.Background(), key, func(context.Context) (any, error) {
+		return fn()
+	})
+}
+
+// DoContext is like Do, but a caller stops waiting as soon as its ctx is
+// done and gets ctx.Err() back. The in-flight call keeps running for the
+// other callers; its context carries the values of the ctx that started it
+// and is cancelled only once every caller has given up.
+// As fn runs in a goroutine of its own, a panic of fn is recovered there
+// and raised again in the callers still waiting.
+func (g *Group) DoContext(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (any, error) {
 	g.mu.Lock()
 	if g.mp == nil {
 		g.mp = make(map[string]*call)
 	}
 
-	if c, ok := g.mp[key]; ok {
-		g.mu.Unlock()
-		c.wg.Wait()
+	c, ok := g.mp[key]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		var fnCtx context.Context
+		fnCtx, c.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		g.mp[key] = c
+		go g.doCall(fnCtx, c, key, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		if c.panicked != nil {
+			panic(c.panicked)
+		}
 		return c.val, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// Nobody is interested anymore, later callers start a fresh call.
+			c.cancel()
+			if g.mp[key] == c {
+				delete(g.mp, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
 	}
+}
 
-	nc := new(call)
-	nc.wg.Add(1)
-	g.mp[key] = nc
-	g.mu.Unlock()
+// errGoexit is returned to the callers when fn called runtime.Goexit.
+var errGoexit = errors.New("singleflight: function called runtime.Goexit")
 
-	nc.val, nc.err = fn()
-	nc.wg.Done()
+// A panicError is a panic of fn, along with the stack of the goroutine which ran it.
+type panicError struct {
+	value any
+	stack []byte
+}
 
-	g.mu.Lock()
-	delete(g.mp, key)
-	g.mu.Unlock()
+func (p *panicError) Error() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+func (p *panicError) Unwrap() error {
+	err, _ := p.value.(error)
+	return err
+}
+
+func (g *Group) doCall(ctx context.Context, c *call, key string, fn func(ctx context.Context) (any, error)) {
+	normalReturn := false
+	defer func() {
+		if !normalReturn {
+			if r := recover(); r != nil {
+				// nobody may be waiting anymore, the panic is then dropped
+				// rather than crashing the process.
+				c.panicked = &panicError{value: r, stack: debug.Stack()}
+			} else {
+				c.err = errGoexit
+			}
+		}
+
+		g.mu.Lock()
+		if g.mp[key] == c {
+			delete(g.mp, key)
+		}
+		g.mu.Unlock()
+
+		close(c.done)
+		c.cancel()
+	}()
 
-	return nc.val, nc.err
+	c.val, c.err = fn(ctx)
+	normalReturn = true
 }
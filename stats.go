@@ -0,0 +1,98 @@
+package geecaches
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// GroupStats is a snapshot of the statistics of a Group.
+type GroupStats struct {
+	Gets           int64 // any Get request, including from peers
+	CacheHits      int64 // either cache was good
+	Loads          int64 // (gets - cacheHits)
+	LoadsDeduped   int64 // after singleflight
+	LocalLoads     int64 // total good local loads
+	LocalLoadErrs  int64 // total bad local loads
+	PeerLoads      int64 // either remote load or remote cache hit (not an error)
+	PeerErrors     int64
+	ServerRequests int64 // gets that came over the network from peers
+
+	MainCache CacheStats
+	HotCache  CacheStats
+}
+
+// CacheStats is a snapshot of the statistics of one of the caches of a Group.
+type CacheStats struct {
+	Items       int64 // including the pinned ones
+	Bytes       int64 // including the pinned ones
+	Gets        int64
+	Hits        int64
+	Evictions   int64
+	PinnedItems int64
+	PinnedBytes int64
+}
+
+// groupStats are the counters behind GroupStats, updated atomically.
+// Gets and CacheHits are taken from the caches, which count them anyway.
+type groupStats struct {
+	loads          atomic.Int64
+	loadsDeduped   atomic.Int64
+	localLoads     atomic.Int64
+	localLoadErrs  atomic.Int64
+	peerLoads      atomic.Int64
+	peerErrors     atomic.Int64
+	serverRequests atomic.Int64
+}
+
+// Stats returns a snapshot of the statistics of the group.
+func (g *Group) Stats() GroupStats {
+	// every Get looks into the main cache first.
+	mainCache, hotCache := g.mainCache.stats(), g.hotCache.stats()
+	return GroupStats{
+		Gets:           mainCache.Gets,
+		CacheHits:      mainCache.Hits + hotCache.Hits,
+		Loads:          g.stats.loads.Load(),
+		LoadsDeduped:   g.stats.loadsDeduped.Load(),
+		LocalLoads:     g.stats.localLoads.Load(),
+		LocalLoadErrs:  g.stats.localLoadErrs.Load(),
+		PeerLoads:      g.stats.peerLoads.Load(),
+		PeerErrors:     g.stats.peerErrors.Load(),
+		ServerRequests: g.stats.serverRequests.Load(),
+		MainCache:      mainCache,
+		HotCache:       hotCache,
+	}
+}
+
+// demandEvictions is the number of evictions of the group made to make room for new pairs,
+// rather than to shrink it to a smaller MaxBytes or to free memory.
+func (g *Group) demandEvictions() int64 {
+	return g.mainCache.demandEvictions() + g.hotCache.demandEvictions()
+}
+
+// counterStripes is the number of cells of a getCounter.
+const counterStripes = 8
+
+// getCounter counts the gets and hits of a cacheShard in cells of their own cache line,
+// so that the goroutines reading on different cores rarely write to the same one.
+type getCounter struct {
+	cells [counterStripes]struct {
+		gets, hits atomic.Int64
+		_          [48]byte // padding to a cache line
+	}
+}
+
+func (c *getCounter) add(hit bool) {
+	cell := &c.cells[rand.Uint32()%counterStripes]
+	cell.gets.Add(1)
+	if hit {
+		cell.hits.Add(1)
+	}
+}
+
+func (c *getCounter) load() (gets, hits int64) {
+	for i := range c.cells {
+		gets += c.cells[i].gets.Load()
+		hits += c.cells[i].hits.Load()
+	}
+	return gets, hits
+}
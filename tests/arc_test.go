@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"fmt"
+	"geecache-s/cachePolicy"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARCScanResistance(t *testing.T) {
+	// every entry takes 10 bytes, the cache holds 10 of them.
+	cache, err := cachePolicy.CreateCache(100, cachePolicy.CacheCallBack{}, cachePolicy.ArcPolicy)
+	assert.NoError(t, err)
+
+	// hot keys are seen twice and move to T2.
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+		_, ok := cache.Get(key)
+		assert.True(t, ok)
+	}
+
+	// a long scan of keys seen once only churns T1.
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("sc%03d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+	}
+
+	for i := 0; i < 5; i++ {
+		_, ok := cache.Get(fmt.Sprintf("hot%d", i))
+		assert.True(t, ok, "hot%d should survive the scan", i)
+	}
+	assert.LessOrEqual(t, cache.Size(), int64(100))
+}
+
+func TestARCGhostHit(t *testing.T) {
+	evicted := make([]string, 0)
+	cache, err := cachePolicy.CreateCache(30, cachePolicy.CacheCallBack{
+		OnEvicted: func(key string, value cachePolicy.Value) {
+			evicted = append(evicted, key)
+		},
+	}, cachePolicy.ArcPolicy)
+	assert.NoError(t, err)
+
+	assert.NoError(t, cache.Add("k1", testValue{size: 8}))
+	assert.NoError(t, cache.Add("k2", testValue{size: 8}))
+	assert.NoError(t, cache.Add("k3", testValue{size: 8}))
+	assert.NoError(t, cache.Add("k4", testValue{size: 8}))
+	assert.Equal(t, []string{"k1"}, evicted)
+
+	// k1 is a ghost now: it is not in the cache, but adding it again
+	// puts it directly in T2, so the next scan victim comes from T1.
+	_, ok := cache.Get("k1")
+	assert.False(t, ok)
+	assert.NoError(t, cache.Add("k1", testValue{size: 8}))
+	assert.NoError(t, cache.Add("k5", testValue{size: 8}))
+	_, ok = cache.Get("k1")
+	assert.True(t, ok)
+	assert.Equal(t, 3, cache.Len())
+}
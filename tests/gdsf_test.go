@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"fmt"
+	geecaches "geecache-s"
+	"geecache-s/cachePolicy"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGDSFEviction(t *testing.T) {
+	evicted := make([]string, 0)
+	cache := cachePolicy.NewGDSFCache(cachePolicy.Options{
+		CacheCallBack: cachePolicy.CacheCallBack{
+			OnEvicted: func(key string, value cachePolicy.Value) {
+				evicted = append(evicted, key)
+			},
+		},
+		MaxBytes: 40,
+	})
+
+	// every entry takes 10 bytes, the cache holds 4 of them.
+	assert.NoError(t, cache.AddWithLoadCost("k1", testValue{size: 8}, time.Time{}, 2*time.Second))
+	assert.NoError(t, cache.AddWithLoadCost("k2", testValue{size: 8}, time.Time{}, time.Millisecond))
+	assert.NoError(t, cache.AddWithLoadCost("k3", testValue{size: 8}, time.Time{}, time.Millisecond))
+	assert.NoError(t, cache.AddWithLoadCost("k4", testValue{size: 8}, time.Time{}, time.Millisecond))
+	cache.Get("k2")
+
+	// the cheapest to load again go first, the most frequently used of them last.
+	assert.NoError(t, cache.AddWithLoadCost("k5", testValue{size: 8}, time.Time{}, time.Millisecond))
+	assert.NoError(t, cache.AddWithLoadCost("k6", testValue{size: 8}, time.Time{}, time.Millisecond))
+	assert.ElementsMatch(t, []string{"k3", "k4"}, evicted)
+	assert.True(t, cache.Contains("k1"))
+	assert.True(t, cache.Contains("k2"))
+
+	// a larger pair is worth less per byte.
+	evicted = evicted[:0]
+	assert.NoError(t, cache.AddWithLoadCost("big", testValue{size: 18}, time.Time{}, 2*time.Second))
+	assert.NotContains(t, evicted, "k1")
+	assert.NoError(t, cache.AddWithLoadCost("small", testValue{size: 5}, time.Time{}, 2*time.Second))
+	assert.Contains(t, evicted, "big")
+	assert.True(t, cache.Contains("k1"))
+}
+
+func TestGroupLoadCost(t *testing.T) {
+	var slowLoads atomic.Int64
+	opts := geecaches.NewGroupOptions()
+	opts.CachePolicy = cachePolicy.GdsfPolicy
+	opts.MaxBytes = 40
+	opts.HotCacheRatio = 0
+	opts.Getter = geecaches.GetterFunc(func(key string) ([]byte, error) {
+		if key == "slow" {
+			slowLoads.Add(1)
+			time.Sleep(20 * time.Millisecond)
+		}
+		return make([]byte, 6), nil
+	})
+	gee := geecaches.NewGroupWithOpts("gdsf", opts)
+
+	if _, err := gee.Get("slow"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := gee.Get(fmt.Sprintf("k%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := gee.Get("slow"); err != nil {
+		t.Fatal(err)
+	}
+	if n := slowLoads.Load(); n != 1 {
+		t.Fatalf("expect the slow key to stay cached, but it was loaded %d times", n)
+	}
+}
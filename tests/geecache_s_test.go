@@ -1,12 +1,17 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 	geecaches "geecache-s"
 	"geecache-s/cachePolicy"
+	pb "geecache-s/geecachespb"
 	"log"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 var db = map[string]string{
@@ -67,3 +72,633 @@ func TestGetGroup(t *testing.T) {
 		t.Fatalf("expect nil, but %s got", group.Name())
 	}
 }
+
+func TestGetContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	gee := geecaches.NewGroup("slow", 2<<10, geecaches.ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			<-release
+			return []byte(key), nil
+		}), cachePolicy.LruPolicy)
+
+	// another caller is waiting for the same key.
+	done := make(chan geecaches.ByteView)
+	go func() {
+		view, err := gee.Get("Tom")
+		if err != nil {
+			t.Errorf("shared load failed: %v", err)
+		}
+		done <- view
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := gee.GetContext(ctx, "Tom"); err != context.DeadlineExceeded {
+		t.Fatalf("expect %v, but %v got", context.DeadlineExceeded, err)
+	}
+
+	close(release)
+	if view := <-done; view.String() != "Tom" {
+		t.Fatalf("expect Tom, but %s got", view)
+	}
+}
+
+func TestGetterPanic(t *testing.T) {
+	fail := true
+	gee := geecaches.NewGroup("panic", 2<<10, geecaches.GetterFunc(
+		func(key string) ([]byte, error) {
+			if fail {
+				panic("getter failed")
+			}
+			return []byte(key), nil
+		}), cachePolicy.LruPolicy)
+
+	// the panic is raised in the caller, where it can be recovered, e.g. by net/http.
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "getter failed") {
+				t.Fatalf("expect the panic of the getter, but %v got", r)
+			}
+		}()
+		gee.Get("Tom")
+	}()
+
+	fail = false
+	if view, err := gee.Get("Tom"); err != nil || view.String() != "Tom" {
+		t.Fatalf("failed to get value of Tom after a panic")
+	}
+}
+
+type fakePeer struct {
+	mu      sync.Mutex
+	gets    int
+	removed []string
+}
+
+func (p *fakePeer) Get(ctx context.Context, in *pb.GetRequest, out *pb.GetResponse) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gets++
+	out.Value = []byte(in.Key)
+	return nil
+}
+
+func (p *fakePeer) Add(in *pb.AddRequest, out *pb.Empty) error {
+	return nil
+}
+
+func (p *fakePeer) Remove(in *pb.RemoveRequest, out *pb.Empty) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removed = append(p.removed, in.Key)
+	return nil
+}
+
+type fakePicker struct {
+	owner *fakePeer
+	peers []*fakePeer
+}
+
+func (p *fakePicker) PickPeer(key string) (geecaches.PeerHandler, bool) {
+	if p.owner == nil {
+		return nil, false
+	}
+	return p.owner, true
+}
+
+func (p *fakePicker) GetAll() []geecaches.PeerHandler {
+	handlers := make([]geecaches.PeerHandler, 0, len(p.peers))
+	for _, peer := range p.peers {
+		handlers = append(handlers, peer)
+	}
+	return handlers
+}
+
+func (p *fakePicker) SelfAddr() string {
+	return "self"
+}
+
+func TestRemove(t *testing.T) {
+	loads := 0
+	gee := geecaches.NewGroup("remove", 2<<10, geecaches.GetterFunc(
+		func(key string) ([]byte, error) {
+			loads++
+			return []byte(key), nil
+		}), cachePolicy.LruPolicy)
+
+	peers := []*fakePeer{{}, {}}
+	picker := &fakePicker{peers: peers}
+	gee.RegisterPeers(picker)
+
+	if _, err := gee.Get("Tom"); err != nil || loads != 1 {
+		t.Fatalf("failed to load Tom")
+	}
+	if err := gee.Remove("Tom"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gee.Get("Tom"); err != nil || loads != 2 {
+		t.Fatalf("Tom should be loaded again after removal")
+	}
+
+	// owned by a peer: the owner and every other peer drop the key.
+	for _, peer := range peers {
+		peer.removed = nil
+	}
+	picker.owner = peers[0]
+	if err := gee.Remove("Jack"); err != nil {
+		t.Fatal(err)
+	}
+	for i, peer := range peers {
+		if !reflect.DeepEqual(peer.removed, []string{"Jack"}) {
+			t.Fatalf("peer %d: expect [Jack] removed, but %v got", i, peer.removed)
+		}
+	}
+}
+
+func TestAddWithTTL(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 2 << 10
+	opts.DefaultTTL = time.Hour
+	gee := geecaches.NewGroupWithOpts("ttl", opts)
+
+	if err := gee.AddWithTTL("Tom", geecaches.ByteView{Bytes: []byte("630")}, 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := gee.Add("Jack", geecaches.ByteView{Bytes: []byte("589")}); err != nil {
+		t.Fatal(err)
+	}
+	if view, err := gee.Get("Tom"); err != nil || view.String() != "630" {
+		t.Fatalf("failed to get value of Tom")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, err := gee.Get("Tom"); err == nil {
+		t.Fatalf("Tom should be expired")
+	}
+	if view, err := gee.Get("Jack"); err != nil || view.String() != "589" {
+		t.Fatalf("Jack should not be expired")
+	}
+}
+
+func TestGroupOnEvicted(t *testing.T) {
+	var evicted []string
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 16
+	opts.HotCacheRatio = 0
+	opts.OnEvicted = func(key string, value geecaches.ByteView, reason cachePolicy.EvictReason) {
+		evicted = append(evicted, fmt.Sprintf("%s=%s:%s", key, value, reason))
+	}
+	gee := geecaches.NewGroupWithOpts("evicted", opts)
+
+	add := func(key, value string, ttl time.Duration) {
+		if err := gee.AddWithTTL(key, geecaches.ByteView{Bytes: []byte(value)}, ttl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("Tom", "630", 0)
+	add("Tom", "631", 0)
+	add("Jack", "589", 0)
+	add("Sam", "567", 0)
+	if err := gee.Remove("Jack"); err != nil {
+		t.Fatal(err)
+	}
+	add("Tom", "630", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	gee.Get("Tom")
+
+	expect := []string{"Tom=630:replaced", "Tom=631:capacity", "Jack=589:removed", "Tom=630:expired"}
+	if !reflect.DeepEqual(evicted, expect) {
+		t.Fatalf("expect %v, but %v got", expect, evicted)
+	}
+}
+
+func TestSetMaxBytes(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 4 << 10
+	opts.HotCacheRatio = 0
+	opts.Shards = 4
+	gee := geecaches.NewGroupWithOpts("resize", opts)
+	for i := 0; i < 100; i++ {
+		if err := gee.Add(fmt.Sprintf("key%03d", i), geecaches.ByteView{Bytes: []byte("value")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := gee.Stats().MainCache; stats.Items != 100 {
+		t.Fatalf("expect 100 items, but %d got", stats.Items)
+	}
+
+	gee.SetMaxBytes(100)
+	if gee.MaxBytes() != 100 {
+		t.Fatalf("expect max bytes 100, but %d got", gee.MaxBytes())
+	}
+	deadline := time.Now().Add(time.Second)
+	for gee.Stats().MainCache.Bytes > 100 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the cache has not been shrunk, %d bytes", gee.Stats().MainCache.Bytes)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	gee.SetMaxBytes(4 << 10)
+	for i := 0; i < 100; i++ {
+		if err := gee.Add(fmt.Sprintf("key%03d", i), geecaches.ByteView{Bytes: []byte("value")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := gee.Stats().MainCache; stats.Bytes <= 100 || stats.Bytes > 4<<10 {
+		t.Fatalf("expect the cache to grow up to 4KB, but %d bytes got", stats.Bytes)
+	}
+}
+
+func TestAddPinned(t *testing.T) {
+	for _, buffered := range []bool{false, true} {
+		opts := geecaches.NewGroupOptions()
+		opts.MaxBytes = 100
+		opts.HotCacheRatio = 0
+		opts.BufferedReads = buffered
+		gee := geecaches.NewGroupWithOpts(fmt.Sprintf("pinned-%v", buffered), opts)
+
+		// "config" and its value take 26 bytes, at most 50 may be pinned.
+		config := geecaches.ByteView{Bytes: make([]byte, 20)}
+		if err := gee.AddPinned("config", config); err != nil {
+			t.Fatal(err)
+		}
+		if err := gee.AddPinned("big", geecaches.ByteView{Bytes: make([]byte, 30)}); err == nil {
+			t.Fatalf("expect pinned entries to be capped")
+		}
+		for i := 0; i < 20; i++ {
+			if err := gee.Add(fmt.Sprintf("key%02d", i), geecaches.ByteView{Bytes: []byte("value")}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := gee.Get("config"); err != nil {
+			t.Fatalf("expect the pinned entry to stay cached, %v", err)
+		}
+		stats := gee.Stats().MainCache
+		if stats.PinnedItems != 1 || stats.PinnedBytes != 26 || stats.Bytes > 100 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+
+		if ok, err := gee.Unpin("config"); !ok || err != nil {
+			t.Fatalf("expect config to be unpinned, %v", err)
+		}
+		if ok, _ := gee.Unpin("config"); ok {
+			t.Fatalf("expect config to be unpinned once")
+		}
+		for i := 20; i < 40; i++ {
+			if err := gee.Add(fmt.Sprintf("key%02d", i), geecaches.ByteView{Bytes: []byte("value")}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := gee.Get("config"); err == nil {
+			t.Fatalf("expect the unpinned entry to be evicted")
+		}
+		if stats := gee.Stats().MainCache; stats.PinnedItems != 0 || stats.PinnedBytes != 0 {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	}
+}
+
+func TestPinnedEvents(t *testing.T) {
+	var reasons []cachePolicy.EvictReason
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 100
+	opts.HotCacheRatio = 0
+	opts.OnEvicted = func(key string, value geecaches.ByteView, reason cachePolicy.EvictReason) {
+		reasons = append(reasons, reason)
+	}
+	gee := geecaches.NewGroupWithOpts("pinned-events", opts)
+
+	if err := gee.AddPinned("a", geecaches.ByteView{Bytes: []byte("1")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gee.AddPinned("a", geecaches.ByteView{Bytes: []byte("2")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gee.Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	expect := []cachePolicy.EvictReason{cachePolicy.EvictReplaced, cachePolicy.EvictRemoved}
+	if !reflect.DeepEqual(reasons, expect) {
+		t.Fatalf("expect reasons %v, but %v got", expect, reasons)
+	}
+}
+
+func TestUnpinTooLarge(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 100
+	opts.HotCacheRatio = 0
+	gee := geecaches.NewGroupWithOpts("unpin-large", opts)
+
+	// "a" and "b" take 21 bytes each.
+	for _, key := range []string{"a", "b"} {
+		if err := gee.AddPinned(key, geecaches.ByteView{Bytes: make([]byte, 20)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// once "a" is unpinned, the policy is left with 30-21 bytes, too few for it.
+	gee.SetMaxBytes(30)
+	if ok, err := gee.Unpin("a"); ok || err == nil {
+		t.Fatalf("expect unpinning to fail")
+	}
+	if _, err := gee.Get("a"); err != nil {
+		t.Fatalf("expect a to stay pinned, %v", err)
+	}
+	if stats := gee.Stats().MainCache; stats.PinnedItems != 2 || stats.PinnedBytes != 42 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMetaGetter(t *testing.T) {
+	loads := make(map[string]int)
+	gee := geecaches.NewGroup("meta", 2<<10, geecaches.MetaGetterFunc(
+		func(ctx context.Context, key string) (geecaches.Result, error) {
+			loads[key]++
+			res := geecaches.Result{Value: []byte(db[key]), Version: 7}
+			switch key {
+			case "Tom":
+				res.NoCache = true
+			case "Jack":
+				res.Expire = time.Now().Add(-time.Second)
+			}
+			return res, nil
+		}), cachePolicy.LruPolicy)
+
+	for i := 0; i < 2; i++ {
+		for k, v := range db {
+			view, err := gee.Get(k)
+			if err != nil || view.String() != v || view.Version() != 7 {
+				t.Fatalf("failed to get value of %s", k)
+			}
+		}
+	}
+
+	expect := map[string]int{"Tom": 2, "Jack": 2, "Sam": 1}
+	if !reflect.DeepEqual(loads, expect) {
+		t.Fatalf("expect loads %v, but %v got", expect, loads)
+	}
+}
+
+func TestExpiredLoad(t *testing.T) {
+	gee := geecaches.NewGroup("expired-load", 10, geecaches.MetaGetterFunc(
+		func(ctx context.Context, key string) (geecaches.Result, error) {
+			return geecaches.Result{Value: []byte("value"), Expire: time.Now().Add(-time.Second)}, nil
+		}), cachePolicy.LruPolicy)
+
+	if err := gee.Add("live", geecaches.ByteView{Bytes: []byte("value")}); err != nil {
+		t.Fatal(err)
+	}
+	// the loaded value has no room besides live, but is not cached at all.
+	if view, err := gee.Get("gone"); err != nil || view.String() != "value" {
+		t.Fatalf("failed to get value of gone")
+	}
+	if stats := gee.Stats().MainCache; stats.Items != 1 || stats.Evictions != 0 {
+		t.Fatalf("expect live to stay cached, but stats are %+v", stats)
+	}
+}
+
+func TestHotCache(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 2 << 10
+	opts.HotCacheSampling = 1
+	gee := geecaches.NewGroupWithOpts("hot", opts)
+
+	peer := &fakePeer{}
+	gee.RegisterPeers(&fakePicker{owner: peer, peers: []*fakePeer{peer}})
+
+	for i := 0; i < 3; i++ {
+		if view, err := gee.Get("Tom"); err != nil || view.String() != "Tom" {
+			t.Fatalf("failed to get value of Tom")
+		}
+	}
+	if peer.gets != 1 {
+		t.Fatalf("expect 1 remote get, but %d got", peer.gets)
+	}
+
+	if err := gee.Remove("Tom"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gee.Get("Tom"); err != nil || peer.gets != 2 {
+		t.Fatalf("Tom should be removed from hot cache")
+	}
+}
+
+func TestHotCacheWithoutPeers(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 80
+	gee := geecaches.NewGroupWithOpts("hot-no-peers", opts)
+
+	// without peers, the main cache gets all of MaxBytes, 8 entries of 10 bytes.
+	for i := 0; i < 8; i++ {
+		if err := gee.Add(fmt.Sprintf("key%d", i), geecaches.ByteView{Bytes: make([]byte, 6)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := gee.Stats().MainCache; stats.Items != 8 {
+		t.Fatalf("expect 8 entries in the main cache, but %d got", stats.Items)
+	}
+
+	// then the hot cache takes its share of it.
+	gee.RegisterPeers(&fakePicker{})
+	deadline := time.Now().Add(time.Second)
+	for gee.Stats().MainCache.Bytes > 70 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect the main cache to shrink to 70 bytes")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHotCacheSmallMaxBytes(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 7
+	opts.HotCacheSampling = 1
+	gee := geecaches.NewGroupWithOpts("hot-small", opts)
+	peer := &fakePeer{}
+	gee.RegisterPeers(&fakePicker{owner: peer, peers: []*fakePeer{peer}})
+
+	// 7 * HotCacheRatio rounds down to 0, which must not leave the hot cache unlimited.
+	for i := 0; i < 100; i++ {
+		if _, err := gee.Get(fmt.Sprintf("key%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := gee.Stats().HotCache; stats.Bytes > 1 {
+		t.Fatalf("expect the hot cache to hold at most 1 byte, but %d got", stats.Bytes)
+	}
+}
+
+func TestStats(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 16
+	opts.HotCacheRatio = 0
+	opts.Getter = geecaches.GetterFunc(func(key string) ([]byte, error) {
+		if v, ok := db[key]; ok {
+			return []byte(v), nil
+		}
+		return nil, fmt.Errorf("%s not exist", key)
+	})
+	gee := geecaches.NewGroupWithOpts("stats", opts)
+
+	gee.Get("Tom")
+	gee.Get("Tom")
+	gee.Get("Jack")
+	gee.Get("Sam")
+	gee.Get("unknown")
+
+	stats := gee.Stats()
+	expect := geecaches.GroupStats{
+		Gets:          5,
+		CacheHits:     1,
+		Loads:         4,
+		LoadsDeduped:  4,
+		LocalLoads:    3,
+		LocalLoadErrs: 1,
+		MainCache: geecaches.CacheStats{
+			Items:     2,
+			Bytes:     int64(len("Jack589Sam567")),
+			Gets:      5,
+			Hits:      1,
+			Evictions: 1,
+		},
+		HotCache: geecaches.CacheStats{Gets: 4},
+	}
+	if !reflect.DeepEqual(stats, expect) {
+		t.Fatalf("expect %+v, but %+v got", expect, stats)
+	}
+}
+
+func TestShards(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 64 << 10
+	opts.HotCacheRatio = 0
+	opts.Shards = 8
+	gee := geecaches.NewGroupWithOpts("shards", opts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("key%d-%d", i, j)
+				gee.Add(key, geecaches.ByteView{Bytes: []byte(key)})
+				if view, err := gee.Get(key); err != nil || view.String() != key {
+					t.Errorf("failed to get value of %s", key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := gee.Stats().MainCache
+	if stats.Items != 800 || stats.Hits != 800 || stats.Bytes > opts.MaxBytes {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestShardsSmallMaxBytes(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 64 << 10
+	opts.HotCacheRatio = 0
+	opts.Shards = 8
+	gee := geecaches.NewGroupWithOpts("shards-small", opts)
+	for c := 'a'; c <= 'z'; c++ {
+		gee.Add(string(c), geecaches.ByteView{Bytes: []byte{}})
+	}
+
+	// fewer bytes than shards, which must not hold 1 byte each.
+	gee.SetMaxBytes(3)
+	deadline := time.Now().Add(time.Second)
+	for gee.Stats().MainCache.Bytes > 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect the cache to shrink to 3 bytes, but %d got", gee.Stats().MainCache.Bytes)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		gee.Add(string(c), geecaches.ByteView{Bytes: []byte{}})
+	}
+	if bytes := gee.Stats().MainCache.Bytes; bytes > 3 {
+		t.Fatalf("expect at most 3 bytes, but %d got", bytes)
+	}
+}
+
+func TestBufferedReads(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 1 << 10
+	opts.HotCacheRatio = 0
+	opts.Shards = 4
+	opts.BufferedReads = true
+	gee := geecaches.NewGroupWithOpts("buffered", opts)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("key%d", j%50)
+				gee.Add(key, geecaches.ByteView{Bytes: []byte(key)})
+				if view, err := gee.Get(key); err == nil && view.String() != key {
+					t.Errorf("expect %s, but %s got", key, view)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// evicted keys must not be served from the map anymore.
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("more%d", i)
+		gee.Add(key, geecaches.ByteView{Bytes: []byte(key)})
+	}
+	stats := gee.Stats().MainCache
+	hits := 0
+	for i := 0; i < 200; i++ {
+		if _, err := gee.Get(fmt.Sprintf("more%d", i)); err == nil {
+			hits++
+		}
+	}
+	if int64(hits) != stats.Items || stats.Bytes > opts.MaxBytes {
+		t.Fatalf("expect %d hits, but %d got", stats.Items, hits)
+	}
+
+	if err := gee.AddWithTTL("Tom", geecaches.ByteView{Bytes: []byte("630")}, time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := gee.Get("Tom"); err == nil {
+		t.Fatalf("Tom should be expired")
+	}
+}
+
+func BenchmarkGroupGetParallel(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		for _, buffered := range []bool{false, true} {
+			b.Run(fmt.Sprintf("shards=%d/buffered=%v", shards, buffered), func(b *testing.B) {
+				benchmarkGroupGetParallel(b, shards, buffered)
+			})
+		}
+	}
+}
+
+func benchmarkGroupGetParallel(b *testing.B, shards int, buffered bool) {
+	opts := geecaches.NewGroupOptions()
+	opts.Shards = shards
+	opts.BufferedReads = buffered
+	gee := geecaches.NewGroupWithOpts(fmt.Sprintf("bench-shards-%d-%v", shards, buffered), opts)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		gee.Add(keys[i], geecaches.ByteView{Bytes: []byte(keys[i])})
+	}
+
+	b.SetParallelism(50)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			gee.Get(keys[i%len(keys)])
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"geecache-s/cachePolicy/generic"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type point struct{ x, y int }
+
+func genericCaches[K comparable, V any](opts generic.Options[K, V]) map[string]generic.Cache[K, V] {
+	return map[string]generic.Cache[K, V]{
+		"lru":     generic.NewLRU(opts),
+		"lfu":     generic.NewLFU(opts),
+		"arc":     generic.NewARC(opts),
+		"tinylfu": generic.NewTinyLFU(opts),
+		"sieve":   generic.NewSIEVE(opts),
+		"s3fifo":  generic.NewS3FIFO(opts),
+		"gdsf":    generic.NewGDSF(opts),
+		"lruk":    generic.NewLRUK(opts, 0),
+		"lirs":    generic.NewLIRS(opts),
+	}
+}
+
+func TestGenericCountLimit(t *testing.T) {
+	// without a cost function, MaxCost is the number of pairs.
+	for name, c := range genericCaches(generic.Options[int, string]{MaxCost: 10}) {
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, c.Add(i, "v"), name)
+		}
+		assert.LessOrEqual(t, c.Len(), 10, name)
+		assert.Equal(t, int64(c.Len()), c.Size(), name)
+	}
+}
+
+func TestGenericCost(t *testing.T) {
+	cost := func(key point, value []byte) int64 { return int64(len(value)) }
+	for name, c := range genericCaches(generic.Options[point, []byte]{MaxCost: 100, Cost: cost}) {
+		assert.NoError(t, c.Add(point{1, 2}, make([]byte, 30)), name)
+		assert.NoError(t, c.Add(point{1, 2}, make([]byte, 40)), name)
+		assert.Equal(t, 1, c.Len(), name)
+		assert.Equal(t, int64(40), c.Size(), name)
+
+		v, ok := c.Get(point{1, 2})
+		assert.True(t, ok, name)
+		assert.Len(t, v, 40, name)
+		_, ok = c.Get(point{2, 1})
+		assert.False(t, ok, name)
+
+		assert.Error(t, c.Add(point{3, 4}, make([]byte, 101)), name)
+		assert.True(t, c.Remove(point{1, 2}), name)
+		assert.Equal(t, int64(0), c.Size(), name)
+	}
+}
+
+func TestGenericOnEvicted(t *testing.T) {
+	evicted := make(map[int]int)
+	opts := generic.Options[int, int]{
+		MaxCost:   8,
+		OnEvicted: func(key int, value int) { evicted[key] = value },
+	}
+	for name, c := range genericCaches(opts) {
+		clear(evicted)
+		for i := 0; i < 20; i++ {
+			assert.NoError(t, c.Add(i, i*i), name)
+		}
+		assert.Equal(t, 20, c.Len()+len(evicted), name)
+		for key, value := range evicted {
+			assert.Equal(t, key*key, value, name)
+			_, ok := c.Get(key)
+			assert.False(t, ok, name)
+		}
+	}
+}
+
+func TestGenericExpire(t *testing.T) {
+	for name, c := range genericCaches(generic.Options[string, int]{}) {
+		assert.NoError(t, c.AddWithExpire("a", 1, time.Now().Add(-time.Second)), name)
+		assert.NoError(t, c.AddWithExpire("b", 2, time.Now().Add(-time.Second)), name)
+		assert.NoError(t, c.AddWithExpire("c", 3, time.Now().Add(time.Hour)), name)
+
+		_, ok := c.Get("a")
+		assert.False(t, ok, name)
+		assert.Equal(t, 1, c.RemoveExpired(), name)
+		v, ok := c.Get("c")
+		assert.True(t, ok, name)
+		assert.Equal(t, 3, v, name)
+	}
+}
+
+func TestGenericOnEvictedWithReason(t *testing.T) {
+	type event struct {
+		key    int
+		reason generic.EvictReason
+		len    int
+	}
+	var events []event
+	var c generic.Cache[int, int]
+	opts := generic.Options[int, int]{
+		MaxCost: 2,
+		OnEvictedWithReason: func(key int, value int, reason generic.EvictReason) {
+			// the cache has been updated when the callback is called.
+			events = append(events, event{key, reason, c.Len()})
+		},
+	}
+	for name, cache := range genericCaches(opts) {
+		c, events = cache, nil
+		assert.NoError(t, c.Add(1, 1), name)
+		assert.NoError(t, c.Add(1, 2), name)
+		assert.Equal(t, []event{{1, generic.EvictReplaced, 1}}, events, name)
+
+		assert.NoError(t, c.AddWithExpire(2, 2, time.Now().Add(-time.Second)), name)
+		_, ok := c.Get(2)
+		assert.False(t, ok, name)
+		assert.True(t, c.Remove(1), name)
+		assert.Equal(t, []event{{1, generic.EvictReplaced, 1}, {2, generic.EvictExpired, 1}, {1, generic.EvictRemoved, 0}}, events, name)
+
+		events = nil
+		for i := 0; i < 4; i++ {
+			assert.NoError(t, c.Add(i, i), name)
+		}
+		assert.NotEmpty(t, events, name)
+		for _, e := range events {
+			assert.Equal(t, generic.EvictCapacity, e.reason, name)
+			assert.LessOrEqual(t, e.len, 2, name)
+		}
+	}
+}
+
+func TestGenericPeek(t *testing.T) {
+	for name, c := range genericCaches(generic.Options[int, int]{}) {
+		assert.False(t, c.Contains(1), name)
+		assert.NoError(t, c.Add(1, 10), name)
+		assert.NoError(t, c.AddWithExpire(2, 20, time.Now().Add(-time.Second)), name)
+
+		v, ok := c.Peek(1)
+		assert.True(t, ok, name)
+		assert.Equal(t, 10, v, name)
+		assert.True(t, c.Contains(1), name)
+		_, ok = c.Peek(2)
+		assert.False(t, ok, name)
+		assert.False(t, c.Contains(2), name)
+	}
+}
+
+func TestGenericPeekKeepsOrder(t *testing.T) {
+	lru := generic.NewLRU(generic.Options[int, int]{MaxCost: 2})
+	assert.NoError(t, lru.Add(1, 1))
+	assert.NoError(t, lru.Add(2, 2))
+	lru.Peek(1)
+	assert.NoError(t, lru.Add(3, 3))
+	assert.False(t, lru.Contains(1), "peeking must not make 1 recently used")
+	assert.True(t, lru.Contains(2))
+}
+
+func TestGenericResize(t *testing.T) {
+	for name, c := range genericCaches(generic.Options[int, int]{MaxCost: 100}) {
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, c.Add(i, i), name)
+		}
+		n := c.Len()
+		evicted := c.Resize(10)
+		assert.LessOrEqual(t, c.Size(), int64(10), name)
+		assert.Equal(t, n-c.Len(), evicted, name)
+
+		for i := 100; i < 200; i++ {
+			assert.NoError(t, c.Add(i, i), name)
+		}
+		assert.LessOrEqual(t, c.Size(), int64(10), name)
+
+		assert.Equal(t, 0, c.Resize(0), name)
+		for i := 200; i < 300; i++ {
+			assert.NoError(t, c.Add(i, i), name)
+		}
+		assert.Greater(t, c.Len(), 10, name)
+	}
+}
+
+func TestGenericPurge(t *testing.T) {
+	removed := 0
+	opts := generic.Options[int, int]{
+		MaxCost: 10,
+		OnEvictedWithReason: func(key int, value int, reason generic.EvictReason) {
+			if reason == generic.EvictRemoved {
+				removed++
+			}
+		},
+	}
+	for name, c := range genericCaches(opts) {
+		removed = 0
+		for i := 0; i < 20; i++ {
+			assert.NoError(t, c.Add(i, i), name)
+		}
+		n := c.Len()
+		c.Purge()
+		assert.Equal(t, n, removed, name)
+		assert.Equal(t, 0, c.Len(), name)
+		assert.Equal(t, int64(0), c.Size(), name)
+		c.Evict()
+
+		assert.NoError(t, c.Add(1, 1), name)
+		v, ok := c.Get(1)
+		assert.True(t, ok, name)
+		assert.Equal(t, 1, v, name)
+	}
+}
+
+func TestGenericAll(t *testing.T) {
+	for name, c := range genericCaches(generic.Options[int, int]{}) {
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, c.Add(i, i*i), name)
+		}
+		assert.NoError(t, c.AddWithExpire(10, 100, time.Now().Add(-time.Second)), name)
+
+		seen := make(map[int]int)
+		for k, v := range c.All() {
+			seen[k] = v
+		}
+		assert.Len(t, seen, 10, name)
+		for k, v := range seen {
+			assert.Equal(t, k*k, v, name)
+		}
+
+		count := 0
+		for range c.All() {
+			count++
+			break
+		}
+		assert.Equal(t, 1, count, name)
+	}
+}
+
+func TestGenericAllOrder(t *testing.T) {
+	lru := generic.NewLRU(generic.Options[string, int]{})
+	lfu := generic.NewLFU(generic.Options[string, int]{})
+	for _, c := range []generic.Cache[string, int]{lru, lfu} {
+		for i, key := range []string{"a", "b", "c"} {
+			assert.NoError(t, c.Add(key, i))
+		}
+		c.Get("a")
+	}
+
+	keys := func(c generic.Cache[string, int]) []string {
+		var keys []string
+		for k := range c.All() {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, keys(lru))
+	assert.Equal(t, []string{"b", "c", "a"}, keys(lfu))
+}
+
+func TestGenericRandomOperations(t *testing.T) {
+	const maxCost = 200
+	cost := func(key int, value []byte) int64 { return int64(len(value)) + 1 }
+	for name, c := range genericCaches(generic.Options[int, []byte]{MaxCost: maxCost, Cost: cost}) {
+		latest := make(map[int]int)
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 20000; i++ {
+			key := rnd.Intn(100)
+			switch rnd.Intn(10) {
+			case 0:
+				c.Remove(key)
+			case 1, 2, 3:
+				n := rnd.Intn(16)
+				assert.NoError(t, c.Add(key, make([]byte, n)), name)
+				latest[key] = n
+			case 4:
+				c.Evict()
+			default:
+				if v, ok := c.Get(key); ok {
+					assert.Len(t, v, latest[key], name)
+				}
+			}
+		}
+
+		var size int64
+		n := 0
+		for key, value := range c.All() {
+			size += cost(key, value)
+			n++
+		}
+		assert.Equal(t, c.Len(), n, name)
+		assert.Equal(t, c.Size(), size, name)
+		assert.LessOrEqual(t, size, int64(maxCost), name)
+	}
+}
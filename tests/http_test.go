@@ -0,0 +1,54 @@
+package tests
+
+import (
+	geecaches "geecache-s"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpSetMaxBytes(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.MaxBytes = 1 << 10
+	gee := geecaches.NewGroupWithOpts("http-resize", opts)
+	pool := geecaches.NewHttpPoolWithOpts("http://localhost:8001", nil)
+
+	serve := func(target string) int {
+		w := httptest.NewRecorder()
+		pool.ServeHTTP(w, httptest.NewRequest(http.MethodPut, target, nil))
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNoContent, serve("/_geecaches/http-resize?maxBytes=2048"))
+	assert.Equal(t, int64(2048), gee.MaxBytes())
+
+	assert.Equal(t, http.StatusBadRequest, serve("/_geecaches/http-resize?maxBytes=-1"))
+	assert.Equal(t, http.StatusBadRequest, serve("/_geecaches/http-resize"))
+	assert.Equal(t, http.StatusNotFound, serve("/_geecaches/unknown?maxBytes=1"))
+	assert.Equal(t, int64(2048), gee.MaxBytes())
+}
+
+func TestHttpRemove(t *testing.T) {
+	gee := geecaches.NewGroupWithOpts("http-remove", geecaches.NewGroupOptions())
+	assert.NoError(t, gee.Add("Tom", geecaches.ByteView{Bytes: []byte("630")}))
+
+	// the owner serves the same group, as both peers run in this process,
+	// thus the entry must be gone once the owner answers, before the caller removes it too.
+	var left []int64
+	owner := geecaches.NewHttpPoolWithOpts("", nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		owner.ServeHTTP(w, r)
+		if r.Method == http.MethodDelete {
+			left = append(left, gee.Stats().MainCache.Items)
+		}
+	}))
+	defer server.Close()
+
+	pool := geecaches.NewHttpPoolWithOpts("http://localhost:8001", nil)
+	pool.SetPeers(server.URL)
+	gee.RegisterPeers(pool)
+
+	assert.NoError(t, gee.Remove("Tom"))
+	assert.Equal(t, []int64{0}, left)
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"geecache-s/cachePolicy"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +16,8 @@ type testValue struct {
 func (v testValue) Size() int64 { return v.size }
 
 func TestLFUBasicOperations(t *testing.T) {
-	cache := cachePolicy.CreateCache(1000, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	cache, err := cachePolicy.CreateCache(1000, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	assert.NoError(t, err)
 
 	_, ok := cache.Get("not_exist")
 	assert.False(t, ok)
@@ -42,7 +44,8 @@ func TestLFUBasicOperations(t *testing.T) {
 }
 
 func TestLFUEvictionFlow(t *testing.T) {
-	cache := cachePolicy.CreateCache(50, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	cache, err := cachePolicy.CreateCache(50, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	assert.NoError(t, err)
 
 	// Add base entries (each entry size: 4 + 3(key) + 5 = 12)
 	assert.NoError(t, cache.Add("key1", testValue{size: 4}))
@@ -70,7 +73,8 @@ func TestLFUEvictionFlow(t *testing.T) {
 }
 
 func TestLFUSameFrequencyEviction(t *testing.T) {
-	cache := cachePolicy.CreateCache(40, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	cache, err := cachePolicy.CreateCache(40, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	assert.NoError(t, err)
 
 	assert.NoError(t, cache.Add("key1", testValue{size: 4}))
 	assert.NoError(t, cache.Add("key2", testValue{size: 4}))
@@ -105,9 +109,10 @@ func TestLFUSameFrequencyEviction(t *testing.T) {
 }
 
 func TestLFUErrorConditions(t *testing.T) {
-	cache := cachePolicy.CreateCache(10, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	cache, err := cachePolicy.CreateCache(10, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	assert.NoError(t, err)
 	largeVal := testValue{size: 20} // 需要20 + key长度 + 4 > 10
-	err := cache.Add("large", largeVal)
+	err = cache.Add("large", largeVal)
 	assert.Error(t, err, "应拒绝过大条目")
 	assert.Equal(t, 0, cache.Len())
 }
@@ -123,10 +128,91 @@ func TestLFUEvictionCallback(t *testing.T) {
 		},
 	}
 
-	cache := cachePolicy.CreateCache(25, cb, cachePolicy.LfuPolicy)
+	cache, err := cachePolicy.CreateCache(25, cb, cachePolicy.LfuPolicy)
+	assert.NoError(t, err)
 	assert.NoError(t, cache.Add("key1", testValue{size: 5}))
 	assert.NoError(t, cache.Add("key2", testValue{size: 15})) // 触发淘汰
 
 	assert.Equal(t, "key1", evictedKey, "应触发淘汰回调")
 	assert.Equal(t, int64(5), evictedValue.Size())
 }
+
+func TestLFURemove(t *testing.T) {
+	cache, err := cachePolicy.CreateCache(1000, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Add("key1", testValue{size: 4}))
+	assert.NoError(t, cache.Add("key2", testValue{size: 4}))
+	_, ok := cache.Get("key1")
+	assert.True(t, ok)
+
+	assert.True(t, cache.Remove("key1"))
+	assert.False(t, cache.Remove("key1"))
+	_, ok = cache.Get("key1")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, int64(4+len("key2")+4), cache.Size())
+
+	// the emptied frequency list must not break eviction.
+	cache.Evict()
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, int64(0), cache.Size())
+}
+
+func TestLFUExpire(t *testing.T) {
+	cache, err := cachePolicy.CreateCache(1000, cachePolicy.CacheCallBack{}, cachePolicy.LfuPolicy)
+	assert.NoError(t, err)
+	assert.NoError(t, cache.AddWithExpire("key1", testValue{size: 4}, time.Now().Add(-time.Second)))
+	assert.NoError(t, cache.AddWithExpire("key2", testValue{size: 4}, time.Now().Add(-time.Second)))
+	assert.NoError(t, cache.AddWithExpire("key3", testValue{size: 4}, time.Now().Add(time.Hour)))
+
+	_, ok := cache.Get("key1")
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.Len())
+
+	assert.Equal(t, 1, cache.RemoveExpired())
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, int64(4+len("key3")+4), cache.Size())
+
+	// re-adding without expiration clears it.
+	assert.NoError(t, cache.AddWithExpire("key3", testValue{size: 4}, time.Now().Add(-time.Second)))
+	assert.NoError(t, cache.Add("key3", testValue{size: 4}))
+	_, ok = cache.Get("key3")
+	assert.True(t, ok)
+}
+
+func TestLFUDecay(t *testing.T) {
+	cache, err := cachePolicy.CreateCacheWithOpts(cachePolicy.LfuPolicy, cachePolicy.Options{
+		MaxBytes:    36, // 3 entries of 12 bytes
+		DecayPeriod: 20 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	// key1 was hot long ago (8).
+	assert.NoError(t, cache.Add("key1", testValue{size: 4}))
+	for i := 0; i < 7; i++ {
+		cache.Get("key1")
+	}
+	assert.NoError(t, cache.Add("key2", testValue{size: 4}))
+
+	time.Sleep(30 * time.Millisecond)
+
+	// the frequency of key1 has been halved (4), key2 is hot now (6).
+	for i := 0; i < 5; i++ {
+		cache.Get("key2")
+	}
+	assert.NoError(t, cache.Add("key3", testValue{size: 4}))
+	// key4 evicts key3, then it is read up to 5.
+	assert.NoError(t, cache.Add("key4", testValue{size: 4}))
+	for i := 0; i < 4; i++ {
+		cache.Get("key4")
+	}
+
+	// key1 is the least frequently used now.
+	assert.NoError(t, cache.Add("key5", testValue{size: 4}))
+	_, ok := cache.Get("key1")
+	assert.False(t, ok, "key1 should be evicted after decay")
+	for _, key := range []string{"key2", "key4", "key5"} {
+		_, ok = cache.Get(key)
+		assert.True(t, ok, "%s should be cached", key)
+	}
+}
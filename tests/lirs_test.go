@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fmt"
+	"geecache-s/cachePolicy"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loopHits reads 120 keys in a loop through a cache holding 100 of them,
+// loading the missing ones, and returns how many reads of the last loops hit.
+func loopHits(t *testing.T, policy cachePolicy.CachePolicy) int {
+	// every entry takes 10 bytes.
+	cache, err := cachePolicy.CreateCache(1000, cachePolicy.CacheCallBack{}, policy)
+	assert.NoError(t, err)
+
+	hits := 0
+	for loop := 0; loop < 10; loop++ {
+		for i := 0; i < 120; i++ {
+			key := fmt.Sprintf("page%03d", i)
+			if _, ok := cache.Get(key); ok {
+				if loop >= 5 {
+					hits++
+				}
+				continue
+			}
+			assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+		}
+	}
+	return hits
+}
+
+func TestLIRSLoop(t *testing.T) {
+	// LRU always evicts the page read next.
+	assert.Equal(t, 0, loopHits(t, cachePolicy.LruPolicy))
+	// LIRS keeps most of the pages as LIR ones and only misses the others.
+	assert.Greater(t, loopHits(t, cachePolicy.LirsPolicy), 5*90)
+}
+
+func TestLIRSPromotion(t *testing.T) {
+	evicted := make([]string, 0)
+	// every entry takes 10 bytes, the LIR pairs take up to 99 bytes and the HIR ones the rest.
+	cache := cachePolicy.NewLIRSCache(cachePolicy.Options{
+		CacheCallBack: cachePolicy.CacheCallBack{
+			OnEvicted: func(key string, value cachePolicy.Value) {
+				evicted = append(evicted, key)
+			},
+		},
+		MaxBytes: 100,
+	})
+	for i := 0; i < 9; i++ {
+		assert.NoError(t, cache.Add(fmt.Sprintf("lir%d", i), testValue{size: 6}))
+	}
+
+	// the LIR pairs are full, new ones are HIR and evicted first.
+	assert.NoError(t, cache.Add("hir0", testValue{size: 6}))
+	assert.NoError(t, cache.Add("hir1", testValue{size: 6}))
+	assert.Equal(t, []string{"hir0"}, evicted)
+
+	// a non-resident HIR key back while it is in the stack becomes LIR,
+	// and the least recently used LIR pair becomes HIR.
+	assert.NoError(t, cache.Add("hir0", testValue{size: 6}))
+	assert.Equal(t, []string{"hir0", "hir1"}, evicted)
+	assert.NoError(t, cache.Add("new", testValue{size: 6}))
+	assert.Equal(t, []string{"hir0", "hir1", "lir0"}, evicted)
+	assert.True(t, cache.Contains("hir0"))
+}
+
+func TestLIRSStackBottom(t *testing.T) {
+	cache, err := cachePolicy.CreateCache(10, cachePolicy.CacheCallBack{}, cachePolicy.LirsPolicy)
+	assert.NoError(t, err)
+	// a is larger than the LIR share and stays HIR, then is evicted to make room for b.
+	assert.NoError(t, cache.Add("a", testValue{size: 9}))
+	assert.NoError(t, cache.Add("b", testValue{size: 2}))
+	cache.Evict()
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, int64(0), cache.Size())
+	assert.False(t, cache.Contains("b"))
+}
+
+func TestLIRSRandomOperations(t *testing.T) {
+	for _, maxBytes := range []int64{10, 64, 500} {
+		cache := cachePolicy.NewLIRSCache(cachePolicy.Options{MaxBytes: maxBytes})
+		rnd := rand.New(rand.NewSource(maxBytes))
+		for i := 0; i < 20000; i++ {
+			key := fmt.Sprintf("k%d", rnd.Intn(40))
+			size := 1 + rnd.Int63n(maxBytes-int64(len(key)))
+			switch rnd.Intn(12) {
+			case 0:
+				cache.Remove(key)
+			case 1:
+				cache.Evict()
+			case 2:
+				if i%500 == 2 {
+					cache.Resize(1 + rnd.Int63n(maxBytes))
+				}
+			case 3:
+				if i%2000 == 3 {
+					cache.Resize(maxBytes)
+				}
+			case 4, 5, 6, 7:
+				cache.Add(key, testValue{size: size})
+			default:
+				cache.Get(key)
+			}
+
+			var bytes int64
+			n := 0
+			for key, value := range cache.All() {
+				bytes += int64(len(key)) + value.Size()
+				n++
+			}
+			if cache.Len() != n || cache.Size() != bytes || bytes < 0 {
+				t.Fatalf("step %d: Len %d and Size %d, but All holds %d pairs of %d bytes", i, cache.Len(), cache.Size(), n, bytes)
+			}
+		}
+	}
+}
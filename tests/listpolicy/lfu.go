@@ -0,0 +1,253 @@
+package listpolicy
+
+import (
+	"container/list"
+	"geecache-s/cachePolicy/generic"
+	"iter"
+	"time"
+)
+
+type lfuEntry[K comparable, V any] struct {
+	freq   int
+	key    K
+	value  V
+	cost   int64
+	expire time.Time
+}
+
+// LFU evicts the least frequently used pair first,
+// and the least recently used one among pairs of the same frequency.
+type LFU[K comparable, V any] struct {
+	opts     options[K, V]
+	freqList *list.List
+	freqMap  map[int]*list.Element
+	entryMap map[K]*list.Element
+
+	// The current total cost of the pairs.
+	curCost int64
+
+	// Frequencies are halved every opts.DecayPeriod, if it is not 0.
+	lastDecay time.Time
+}
+
+func NewLFU[K comparable, V any](opts generic.Options[K, V]) *LFU[K, V] {
+	return &LFU[K, V]{
+		opts:      options[K, V]{opts},
+		freqList:  list.New(),
+		freqMap:   make(map[int]*list.Element),
+		entryMap:  make(map[K]*list.Element),
+		lastDecay: time.Now(),
+	}
+}
+
+func (lfu *LFU[K, V]) Get(key K) (value V, ok bool) {
+	lfu.maybeDecay()
+	if v, ok := lfu.entryMap[key]; ok {
+		if expired(v.Value.(*lfuEntry[K, V]).expire, time.Now()) {
+			lfu.removeElement(v, EvictExpired)
+			return value, false
+		}
+		lfu.increaseFreq(v)
+		return v.Value.(*lfuEntry[K, V]).value, true
+	}
+	return value, false
+}
+
+func (lfu *LFU[K, V]) Add(key K, value V) error {
+	return lfu.AddWithExpire(key, value, time.Time{})
+}
+
+func (lfu *LFU[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := lfu.opts.cost(key, value)
+	if err := lfu.opts.checkCost(cost); err != nil {
+		return err
+	}
+	lfu.maybeDecay()
+
+	maxCost := lfu.opts.MaxCost
+	if v, ok := lfu.entryMap[key]; ok {
+		entry := v.Value.(*lfuEntry[K, V])
+		old := entry.value
+		lfu.curCost += cost - entry.cost
+		entry.value, entry.cost, entry.expire = value, cost, expire
+		lfu.increaseFreq(v)
+		lfu.opts.evicted(key, old, EvictReplaced)
+		// the pair itself may be evicted if it is still the least frequently used one.
+		for maxCost != 0 && lfu.curCost > maxCost {
+			lfu.Evict()
+		}
+	} else {
+		for maxCost != 0 && lfu.curCost+cost > maxCost {
+			lfu.Evict()
+		}
+		entry := &lfuEntry[K, V]{
+			freq:   1,
+			key:    key,
+			value:  value,
+			cost:   cost,
+			expire: expire,
+		}
+		if _, ok := lfu.freqMap[1]; !ok {
+			lfu.freqMap[1] = lfu.freqList.PushFront(list.New())
+		}
+		lfu.entryMap[key] = lfu.freqMap[1].Value.(*list.List).PushBack(entry)
+		lfu.curCost += cost
+	}
+
+	return nil
+}
+
+func (lfu *LFU[K, V]) Evict() {
+	front := lfu.freqList.Front()
+	if front == nil {
+		return
+	}
+	lfu.removeElement(front.Value.(*list.List).Front(), EvictCapacity)
+}
+
+func (lfu *LFU[K, V]) Remove(key K) bool {
+	if v, ok := lfu.entryMap[key]; ok {
+		lfu.removeElement(v, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (lfu *LFU[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for _, v := range lfu.entryMap {
+		if expired(v.Value.(*lfuEntry[K, V]).expire, now) {
+			lfu.removeElement(v, EvictExpired)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (lfu *LFU[K, V]) Peek(key K) (value V, ok bool) {
+	if v, ok := lfu.entryMap[key]; ok {
+		entry := v.Value.(*lfuEntry[K, V])
+		if !expired(entry.expire, time.Now()) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (lfu *LFU[K, V]) Contains(key K) bool {
+	_, ok := lfu.Peek(key)
+	return ok
+}
+
+func (lfu *LFU[K, V]) Resize(maxCost int64) int {
+	lfu.opts.MaxCost = maxCost
+	return shrink(lfu, maxCost)
+}
+
+func (lfu *LFU[K, V]) Purge() {
+	for elem := lfu.freqList.Front(); elem != nil; elem = lfu.freqList.Front() {
+		lfu.removeElement(elem.Value.(*list.List).Front(), EvictRemoved)
+	}
+}
+
+func (lfu *LFU[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for elem := lfu.freqList.Front(); elem != nil; elem = elem.Next() {
+			for v := elem.Value.(*list.List).Front(); v != nil; v = v.Next() {
+				entry := v.Value.(*lfuEntry[K, V])
+				if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (lfu *LFU[K, V]) Len() int {
+	return len(lfu.entryMap)
+}
+
+func (lfu *LFU[K, V]) Size() int64 {
+	return lfu.curCost
+}
+
+func (lfu *LFU[K, V]) increaseFreq(v *list.Element) {
+	entry := v.Value.(*lfuEntry[K, V])
+	elem := lfu.freqMap[entry.freq]
+	entryList := elem.Value.(*list.List)
+
+	// insert into the list of freq+1.
+	entry.freq++
+	if _, ok := lfu.freqMap[entry.freq]; !ok {
+		lfu.freqMap[entry.freq] = lfu.freqList.InsertAfter(list.New(), elem)
+	}
+	lfu.entryMap[entry.key] = lfu.freqMap[entry.freq].Value.(*list.List).PushBack(entry)
+
+	// remove entry from old list.
+	entryList.Remove(v)
+	if entryList.Len() == 0 {
+		lfu.freqList.Remove(elem)
+		delete(lfu.freqMap, entry.freq-1)
+	}
+}
+
+// maybeDecay halves the frequencies of all entries once per elapsed decay period,
+// so that the entries which were popular long ago are eventually evicted.
+func (lfu *LFU[K, V]) maybeDecay() {
+	period := lfu.opts.DecayPeriod
+	if period <= 0 {
+		return
+	}
+	periods := time.Since(lfu.lastDecay) / period
+	if periods == 0 {
+		return
+	}
+	lfu.lastDecay = lfu.lastDecay.Add(periods * period)
+	lfu.decay(uint(min(periods, 63)))
+}
+
+// decay divides the frequencies of all entries by 2^shift, keeping them at least 1.
+// Lists whose frequencies become equal are merged, in the order they were.
+func (lfu *LFU[K, V]) decay(shift uint) {
+	lfu.freqMap = make(map[int]*list.Element, len(lfu.freqMap))
+	var prev *list.Element
+	for elem := lfu.freqList.Front(); elem != nil; {
+		next := elem.Next()
+		entryList := elem.Value.(*list.List)
+		freq := max(1, entryList.Front().Value.(*lfuEntry[K, V]).freq>>shift)
+
+		if prev != nil && prev.Value.(*list.List).Front().Value.(*lfuEntry[K, V]).freq == freq {
+			prevList := prev.Value.(*list.List)
+			for v := entryList.Front(); v != nil; v = v.Next() {
+				entry := v.Value.(*lfuEntry[K, V])
+				entry.freq = freq
+				lfu.entryMap[entry.key] = prevList.PushBack(entry)
+			}
+			lfu.freqList.Remove(elem)
+		} else {
+			for v := entryList.Front(); v != nil; v = v.Next() {
+				v.Value.(*lfuEntry[K, V]).freq = freq
+			}
+			lfu.freqMap[freq] = elem
+			prev = elem
+		}
+		elem = next
+	}
+}
+
+func (lfu *LFU[K, V]) removeElement(v *list.Element, reason EvictReason) {
+	entry := v.Value.(*lfuEntry[K, V])
+	elem := lfu.freqMap[entry.freq]
+	entryList := elem.Value.(*list.List)
+
+	lfu.curCost -= entry.cost
+
+	delete(lfu.entryMap, entry.key)
+	entryList.Remove(v)
+	if entryList.Len() == 0 {
+		delete(lfu.freqMap, entry.freq)
+		lfu.freqList.Remove(elem)
+	}
+	lfu.opts.evicted(entry.key, entry.value, reason)
+}
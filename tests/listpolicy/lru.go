@@ -0,0 +1,157 @@
+package listpolicy
+
+import (
+	"container/list"
+	"geecache-s/cachePolicy/generic"
+	"iter"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	cost   int64
+	expire time.Time
+}
+
+// LRU evicts the least recently used pair first.
+type LRU[K comparable, V any] struct {
+	opts     options[K, V]
+	usedMap  map[K]*list.Element
+	usedList *list.List
+
+	// The current total cost of the pairs.
+	curCost int64
+}
+
+func NewLRU[K comparable, V any](opts generic.Options[K, V]) *LRU[K, V] {
+	return &LRU[K, V]{
+		opts:     options[K, V]{opts},
+		usedMap:  make(map[K]*list.Element),
+		usedList: list.New(),
+	}
+}
+
+func (lru *LRU[K, V]) Get(key K) (value V, ok bool) {
+	if elem, ok := lru.usedMap[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		if expired(entry.expire, time.Now()) {
+			lru.removeElement(elem, EvictExpired)
+			return value, false
+		}
+		lru.usedList.MoveToFront(elem)
+		return entry.value, true
+	}
+	return value, false
+}
+
+func (lru *LRU[K, V]) Add(key K, value V) error {
+	return lru.AddWithExpire(key, value, time.Time{})
+}
+
+func (lru *LRU[K, V]) AddWithExpire(key K, value V, expire time.Time) error {
+	cost := lru.opts.cost(key, value)
+	if err := lru.opts.checkCost(cost); err != nil {
+		return err
+	}
+
+	if elem, ok := lru.usedMap[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		old := entry.value
+		lru.curCost += cost - entry.cost
+		entry.value, entry.cost, entry.expire = value, cost, expire
+		lru.usedList.MoveToFront(elem)
+		lru.opts.evicted(key, old, EvictReplaced)
+	} else {
+		lru.usedMap[key] = lru.usedList.PushFront(&lruEntry[K, V]{key, value, cost, expire})
+		lru.curCost += cost
+	}
+
+	for lru.opts.MaxCost != 0 && lru.curCost > lru.opts.MaxCost {
+		lru.Evict()
+	}
+	return nil
+}
+
+func (lru *LRU[K, V]) Evict() {
+	back := lru.usedList.Back()
+	if back == nil {
+		return
+	}
+	lru.removeElement(back, EvictCapacity)
+}
+
+func (lru *LRU[K, V]) Remove(key K) bool {
+	if elem, ok := lru.usedMap[key]; ok {
+		lru.removeElement(elem, EvictRemoved)
+		return true
+	}
+	return false
+}
+
+func (lru *LRU[K, V]) RemoveExpired() int {
+	now, removed := time.Now(), 0
+	for elem := lru.usedList.Front(); elem != nil; {
+		next := elem.Next()
+		if expired(elem.Value.(*lruEntry[K, V]).expire, now) {
+			lru.removeElement(elem, EvictExpired)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+func (lru *LRU[K, V]) Peek(key K) (value V, ok bool) {
+	if elem, ok := lru.usedMap[key]; ok {
+		entry := elem.Value.(*lruEntry[K, V])
+		if !expired(entry.expire, time.Now()) {
+			return entry.value, true
+		}
+	}
+	return value, false
+}
+
+func (lru *LRU[K, V]) Contains(key K) bool {
+	_, ok := lru.Peek(key)
+	return ok
+}
+
+func (lru *LRU[K, V]) Resize(maxCost int64) int {
+	lru.opts.MaxCost = maxCost
+	return shrink(lru, maxCost)
+}
+
+func (lru *LRU[K, V]) Purge() {
+	for elem := lru.usedList.Back(); elem != nil; elem = lru.usedList.Back() {
+		lru.removeElement(elem, EvictRemoved)
+	}
+}
+
+func (lru *LRU[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		for elem := lru.usedList.Back(); elem != nil; elem = elem.Prev() {
+			entry := elem.Value.(*lruEntry[K, V])
+			if !expired(entry.expire, now) && !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+func (lru *LRU[K, V]) Len() int {
+	return len(lru.usedMap)
+}
+
+func (lru *LRU[K, V]) Size() int64 {
+	return lru.curCost
+}
+
+func (lru *LRU[K, V]) removeElement(elem *list.Element, reason EvictReason) {
+	entry := elem.Value.(*lruEntry[K, V])
+	lru.curCost -= entry.cost
+	delete(lru.usedMap, entry.key)
+	lru.usedList.Remove(elem)
+	lru.opts.evicted(entry.key, entry.value, reason)
+}
@@ -0,0 +1,59 @@
+// Package listpolicy keeps the LRU and LFU policies of cachePolicy/generic as they were
+// on top of container/list, before they moved to slice-backed intrusive lists,
+// so that benchmarks can compare both.
+package listpolicy
+
+import (
+	"fmt"
+	"geecache-s/cachePolicy/generic"
+	"time"
+)
+
+type EvictReason = generic.EvictReason
+
+const (
+	EvictCapacity = generic.EvictCapacity
+	EvictExpired  = generic.EvictExpired
+	EvictRemoved  = generic.EvictRemoved
+	EvictReplaced = generic.EvictReplaced
+)
+
+// options adds the helpers of generic.Options the policies use.
+type options[K comparable, V any] struct {
+	generic.Options[K, V]
+}
+
+func (opts *options[K, V]) cost(key K, value V) int64 {
+	if opts.Cost == nil {
+		return 1
+	}
+	return opts.Cost(key, value)
+}
+
+func (opts *options[K, V]) evicted(key K, value V, reason EvictReason) {
+	if reason == EvictCapacity && opts.OnEvicted != nil {
+		opts.OnEvicted(key, value)
+	}
+	if opts.OnEvictedWithReason != nil {
+		opts.OnEvictedWithReason(key, value, reason)
+	}
+}
+
+func (opts *options[K, V]) checkCost(cost int64) error {
+	if opts.MaxCost != 0 && cost > opts.MaxCost {
+		return fmt.Errorf("the cost of the pair is too large, need less than %d which is %d", opts.MaxCost, cost)
+	}
+	return nil
+}
+
+func shrink[K comparable, V any](c generic.Cache[K, V], maxCost int64) int {
+	n := c.Len()
+	for maxCost != 0 && c.Len() > 0 && c.Size() > maxCost {
+		c.Evict()
+	}
+	return n - c.Len()
+}
+
+func expired(expire time.Time, now time.Time) bool {
+	return !expire.IsZero() && !now.Before(expire)
+}
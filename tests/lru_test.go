@@ -4,6 +4,7 @@ import (
 	"geecache-s/cachePolicy"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type String string
@@ -13,7 +14,10 @@ func (d String) Size() int64 {
 }
 
 func TestLRUGet(t *testing.T) {
-	lru := cachePolicy.CreateCache(int64(0), cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	lru, err := cachePolicy.CreateCache(int64(0), cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lru.Add("key1", String("1234"))
 	if v, ok := lru.Get("key1"); !ok || string(v.(String)) != "1234" {
 		t.Fatalf("cache hit key1=1234 failed")
@@ -27,7 +31,10 @@ func TestRemoveoldest(t *testing.T) {
 	k1, k2, k3 := "key1", "key2", "k3"
 	v1, v2, v3 := "value1", "value2", "v3"
 	cap := int64(len(k1 + k2 + v1 + v2))
-	lru := cachePolicy.CreateCache(cap, cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	lru, err := cachePolicy.CreateCache(cap, cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lru.Add(k1, String(v1))
 	lru.Add(k2, String(v2))
 	lru.Add(k3, String(v3))
@@ -42,7 +49,10 @@ func TestOnEvicted(t *testing.T) {
 	callback := func(key string, value cachePolicy.Value) {
 		keys = append(keys, key)
 	}
-	lru := cachePolicy.CreateCache(int64(10), cachePolicy.CacheCallBack{OnEvicted: callback}, cachePolicy.LruPolicy)
+	lru, err := cachePolicy.CreateCache(int64(10), cachePolicy.CacheCallBack{OnEvicted: callback}, cachePolicy.LruPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lru.Add("key1", String("123456"))
 	lru.Add("k2", String("k2"))
 	lru.Add("k3", String("k3"))
@@ -56,7 +66,10 @@ func TestOnEvicted(t *testing.T) {
 }
 
 func TestAdd(t *testing.T) {
-	lru := cachePolicy.CreateCache(int64(0), cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	lru, err := cachePolicy.CreateCache(int64(0), cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
 	lru.Add("key", String("1"))
 	lru.Add("key", String("111"))
 
@@ -64,3 +77,40 @@ func TestAdd(t *testing.T) {
 		t.Fatal("expected 6 but got", lru.Len())
 	}
 }
+
+func TestLRURemove(t *testing.T) {
+	lru, err := cachePolicy.CreateCache(int64(0), cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lru.Add("key1", String("1234"))
+	lru.Add("key2", String("5678"))
+
+	if !lru.Remove("key1") || lru.Remove("key1") {
+		t.Fatalf("remove key1 failed")
+	}
+	if _, ok := lru.Get("key1"); ok || lru.Len() != 1 || lru.Size() != int64(len("key2")+len("5678")) {
+		t.Fatalf("key1 should be removed")
+	}
+}
+
+func TestLRUExpire(t *testing.T) {
+	lru, err := cachePolicy.CreateCache(int64(0), cachePolicy.CacheCallBack{}, cachePolicy.LruPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lru.AddWithExpire("key1", String("1234"), time.Now().Add(-time.Second))
+	lru.AddWithExpire("key2", String("1234"), time.Now().Add(-time.Second))
+	lru.AddWithExpire("key3", String("1234"), time.Now().Add(time.Hour))
+	lru.Add("key4", String("1234"))
+
+	if _, ok := lru.Get("key1"); ok || lru.Len() != 3 {
+		t.Fatalf("key1 should be expired")
+	}
+	if n := lru.RemoveExpired(); n != 1 || lru.Len() != 2 {
+		t.Fatalf("expect 1 expired pair removed, but %d got", n)
+	}
+	if _, ok := lru.Get("key3"); !ok {
+		t.Fatalf("key3 should not be expired")
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"fmt"
+	"geecache-s/cachePolicy"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUKScan(t *testing.T) {
+	// every entry takes 10 bytes, the cache holds 10 of them.
+	cache := cachePolicy.NewLRUKCache(cachePolicy.Options{MaxBytes: 100}, 0)
+
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+		cache.Get(key)
+	}
+
+	// a scan of keys used once evicts them among themselves, however recent they are.
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("scan%03d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+	}
+	for i := 0; i < 5; i++ {
+		assert.True(t, cache.Contains(fmt.Sprintf("hot%d", i)), "hot%d should not be evicted", i)
+	}
+	assert.Equal(t, 10, cache.Len())
+
+	// the history of an evicted key is kept, so coming back makes it its second access.
+	assert.False(t, cache.Contains("scan090"))
+	assert.NoError(t, cache.Add("scan090", testValue{size: 3}))
+	for i := 100; i < 120; i++ {
+		key := fmt.Sprintf("scan%03d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+	}
+	assert.True(t, cache.Contains("scan090"))
+}
+
+func TestLRUKThirdAccess(t *testing.T) {
+	cache := cachePolicy.NewLRUKCache(cachePolicy.Options{MaxBytes: 20}, 3)
+	assert.NoError(t, cache.Add("a", testValue{size: 9}))
+	cache.Get("a")
+	cache.Get("a")
+	assert.NoError(t, cache.Add("b", testValue{size: 9}))
+	cache.Get("b")
+
+	// b was accessed only twice, thus goes before a although it is more recent.
+	assert.NoError(t, cache.Add("c", testValue{size: 9}))
+	assert.True(t, cache.Contains("a"))
+	assert.False(t, cache.Contains("b"))
+}
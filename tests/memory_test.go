@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"fmt"
+	geecaches "geecache-s"
+	"testing"
+	"time"
+)
+
+func TestMemoryManager(t *testing.T) {
+	manager := geecaches.NewMemoryManager(10<<10, 0)
+	defer manager.Close()
+
+	newGroup := func(name string) *geecaches.Group {
+		opts := geecaches.NewGroupOptions()
+		opts.HotCacheRatio = 0
+		opts.MemoryManager = manager
+		opts.MinBytes = 1 << 10
+		opts.Getter = geecaches.GetterFunc(func(key string) ([]byte, error) {
+			return make([]byte, 64), nil
+		})
+		return geecaches.NewGroupWithOpts(name, opts)
+	}
+	busy, idle := newGroup("memory-busy"), newGroup("memory-idle")
+	if busy.MaxBytes() != 1<<10 || idle.MaxBytes() != 1<<10 {
+		t.Fatalf("expect both groups to start with their minimum, but %d and %d got", busy.MaxBytes(), idle.MaxBytes())
+	}
+
+	load := func(from, to int) {
+		for i := from; i < to; i++ {
+			if _, err := busy.Get(fmt.Sprintf("key%d", i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	load(0, 200)
+	manager.Rebalance()
+	if idle.MaxBytes() != 1<<10 {
+		t.Fatalf("expect the idle group to keep its minimum, but %d got", idle.MaxBytes())
+	}
+	if busy.MaxBytes() < 8<<10 || busy.MaxBytes()+idle.MaxBytes() > 10<<10 {
+		t.Fatalf("expect the busy group to get the rest of the budget, but %d got", busy.MaxBytes())
+	}
+
+	// a maximum caps what the group is given.
+	load(200, 400)
+	manager.Register(busy, 1<<10, 4<<10)
+	if busy.MaxBytes() != 4<<10 {
+		t.Fatalf("expect the busy group to get its maximum, but %d got", busy.MaxBytes())
+	}
+
+	// memory is taken back when the budget shrinks.
+	manager.SetMaxBytes(2 << 10)
+	if busy.MaxBytes()+idle.MaxBytes() > 2<<10 {
+		t.Fatalf("expect the groups to fit in 2KB, but %d and %d got", busy.MaxBytes(), idle.MaxBytes())
+	}
+	manager.SetMaxBytes(1 << 10)
+	if busy.MaxBytes() != 512 || idle.MaxBytes() != 512 {
+		t.Fatalf("expect the minimums to be scaled down alike, but %d and %d got", busy.MaxBytes(), idle.MaxBytes())
+	}
+
+	manager.Unregister(busy)
+	manager.SetMaxBytes(10 << 10)
+	if busy.MaxBytes() != 512 {
+		t.Fatalf("expect an unregistered group to keep its limit, but %d got", busy.MaxBytes())
+	}
+}
+
+func TestMemoryManagerShrinkIsNotDemand(t *testing.T) {
+	manager := geecaches.NewMemoryManager(10<<10, 0)
+	defer manager.Close()
+
+	opts := geecaches.NewGroupOptions()
+	opts.HotCacheRatio = 0
+	opts.MemoryManager = manager
+	opts.MinBytes = 1 << 10
+	opts.Getter = geecaches.GetterFunc(func(key string) ([]byte, error) {
+		return make([]byte, 64), nil
+	})
+	gee := geecaches.NewGroupWithOpts("memory-shrunk", opts)
+	for i := 0; i < 200; i++ {
+		if _, err := gee.Get(fmt.Sprintf("key%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manager.Rebalance()
+
+	// the evictions made to fit in the smaller budget are not a sign of demand.
+	manager.SetMaxBytes(1 << 10)
+	deadline := time.Now().Add(time.Second)
+	for gee.Stats().MainCache.Bytes > gee.MaxBytes() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect the group to shrink to %d bytes", gee.MaxBytes())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	manager.SetMaxBytes(10 << 10)
+	if gee.MaxBytes() > 4<<10 {
+		t.Fatalf("expect the idle group to get its usage and some headroom, but %d got", gee.MaxBytes())
+	}
+}
+
+func TestMemoryManagerRegisterPeers(t *testing.T) {
+	manager := geecaches.NewMemoryManager(10<<10, time.Millisecond)
+	defer manager.Close()
+
+	opts := geecaches.NewGroupOptions()
+	opts.MemoryManager = manager
+	gee := geecaches.NewGroupWithOpts("memory-peers", opts)
+	// the manager sets the limit of the group from its own goroutine meanwhile.
+	time.Sleep(5 * time.Millisecond)
+	gee.RegisterPeers(&fakePicker{})
+	time.Sleep(5 * time.Millisecond)
+}
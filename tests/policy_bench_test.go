@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"geecache-s/cachePolicy/generic"
+	"geecache-s/tests/listpolicy"
+	"testing"
+)
+
+// benchCache is the part of a cache the benchmarks use.
+type benchCache interface {
+	Get(key int) (int, bool)
+	Add(key int, value int) error
+}
+
+const benchCacheLen = 1024
+
+func benchPolicies(policy string) map[string]func() benchCache {
+	opts := generic.Options[int, int]{MaxCost: benchCacheLen}
+	if policy == "lru" {
+		return map[string]func() benchCache{
+			"intrusive": func() benchCache { return generic.NewLRU(opts) },
+			"list":      func() benchCache { return listpolicy.NewLRU(opts) },
+		}
+	}
+	return map[string]func() benchCache{
+		"intrusive": func() benchCache { return generic.NewLFU(opts) },
+		"list":      func() benchCache { return listpolicy.NewLFU(opts) },
+	}
+}
+
+// benchmarkAdd adds 4 times more keys than the cache holds, so that most Adds evict.
+func benchmarkAdd(b *testing.B, policy string) {
+	for name, newCache := range benchPolicies(policy) {
+		b.Run(name, func(b *testing.B) {
+			c := newCache()
+			for i := 0; i < 4*benchCacheLen; i++ {
+				c.Add(i, i)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Add(i%(4*benchCacheLen), i)
+			}
+		})
+	}
+}
+
+// benchmarkGet reads keys which are all cached, and a few which are not.
+func benchmarkGet(b *testing.B, policy string) {
+	for name, newCache := range benchPolicies(policy) {
+		b.Run(name, func(b *testing.B) {
+			c := newCache()
+			for i := 0; i < benchCacheLen; i++ {
+				c.Add(i, i)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Get(i % (benchCacheLen + benchCacheLen/8))
+			}
+		})
+	}
+}
+
+func BenchmarkLRUAdd(b *testing.B) { benchmarkAdd(b, "lru") }
+func BenchmarkLRUGet(b *testing.B) { benchmarkGet(b, "lru") }
+func BenchmarkLFUAdd(b *testing.B) { benchmarkAdd(b, "lfu") }
+func BenchmarkLFUGet(b *testing.B) { benchmarkGet(b, "lfu") }
+
+func TestIntrusiveNoAllocs(t *testing.T) {
+	for _, policy := range []string{"lru", "lfu"} {
+		c := benchPolicies(policy)["intrusive"]()
+		for i := 0; i < 4*benchCacheLen; i++ {
+			c.Add(i, i)
+		}
+		i := 0
+		allocs := testing.AllocsPerRun(1000, func() {
+			c.Add(i%(4*benchCacheLen), i)
+			c.Get(i % benchCacheLen)
+			i++
+		})
+		if allocs != 0 {
+			t.Fatalf("%s: expect no allocation in steady state, but %v got", policy, allocs)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"fmt"
+	geecaches "geecache-s"
+	"geecache-s/cachePolicy"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"testing"
+	"time"
+)
+
+func TestEntryOverhead(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.HotCacheRatio = 0
+	gee := geecaches.NewGroupWithOpts("overhead", opts)
+
+	// the keys are made beforehand and the values are empty, only the overhead is left.
+	const n = 100000
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for _, key := range keys {
+		if err := gee.Add(key, geecaches.ByteView{Bytes: []byte{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(keys)
+
+	perEntry := (int64(after.HeapAlloc) - int64(before.HeapAlloc)) / n
+	if perEntry < cachePolicy.EntryOverhead/2 || perEntry > cachePolicy.EntryOverhead*3/2 {
+		t.Fatalf("expect about %d bytes per entry, but %d got", cachePolicy.EntryOverhead, perEntry)
+	}
+}
+
+func TestWatchHeapPressure(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.HotCacheRatio = 0
+	gee := geecaches.NewGroupWithOpts("pressure", opts)
+	for i := 0; i < 64; i++ {
+		if err := gee.Add(fmt.Sprintf("key%d", i), geecaches.ByteView{Bytes: make([]byte, 256<<10)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := gee.Stats().MainCache.Bytes
+
+	runtime.GC()
+	samples := []metrics.Sample{{Name: "/gc/heap/live:bytes"}}
+	metrics.Read(samples)
+	live := int64(samples[0].Value.Uint64())
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(live + live/20))
+
+	pressureOpts := geecaches.NewHeapPressureOptions()
+	pressureOpts.Interval = 10 * time.Millisecond
+	stop := geecaches.WatchHeapPressure(pressureOpts)
+	defer stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for gee.Stats().MainCache.Bytes >= before {
+		if time.Now().After(deadline) {
+			t.Fatalf("nothing has been evicted under pressure, %d bytes", before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if gee.MaxBytes() != 0 {
+		t.Fatalf("expect max bytes to be left untouched, but %d got", gee.MaxBytes())
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	geecaches "geecache-s"
+	"geecache-s/cachePolicy"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// optsCache is an unlimited LRU which remembers the options it was created with.
+type optsCache struct {
+	cachePolicy.Cache
+	maxBytes int64
+}
+
+func TestRegisterPolicy(t *testing.T) {
+	cachePolicy.Register("test-opts", func(opts cachePolicy.Options) cachePolicy.Cache {
+		lru, _ := cachePolicy.CreateCache(0, opts.CacheCallBack, cachePolicy.LruPolicy)
+		return &optsCache{Cache: lru, maxBytes: opts.MaxBytes}
+	})
+	assert.Contains(t, cachePolicy.Policies(), cachePolicy.CachePolicy("test-opts"))
+	assert.Contains(t, cachePolicy.Policies(), cachePolicy.LruPolicy)
+	assert.Contains(t, cachePolicy.Policies(), cachePolicy.LfuPolicy)
+
+	cache, err := cachePolicy.CreateCache(100, cachePolicy.CacheCallBack{}, "test-opts")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), cache.(*optsCache).maxBytes)
+
+	assert.Panics(t, func() {
+		cachePolicy.Register("test-opts", func(opts cachePolicy.Options) cachePolicy.Cache { return nil })
+	})
+	assert.Panics(t, func() { cachePolicy.Register("test-nil", nil) })
+}
+
+func TestCreateUnknownPolicy(t *testing.T) {
+	cache, err := cachePolicy.CreateCache(100, cachePolicy.CacheCallBack{}, "unknown")
+	assert.Error(t, err)
+	assert.Nil(t, cache)
+}
+
+func TestGroupUnknownPolicy(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.CachePolicy = "lur"
+	assert.Panics(t, func() { geecaches.NewGroupWithOpts("unknown-policy", opts) })
+	assert.Nil(t, geecaches.GetGroup("unknown-policy"))
+}
+
+func TestEvictEmpty(t *testing.T) {
+	for _, policy := range cachePolicy.Policies() {
+		cache, err := cachePolicy.CreateCache(10, cachePolicy.CacheCallBack{}, policy)
+		assert.NoError(t, err)
+		assert.NotPanics(t, cache.Evict, policy)
+		assert.Equal(t, 0, cache.Len(), policy)
+	}
+}
+
+func TestPolicyBasicOperations(t *testing.T) {
+	for _, policy := range cachePolicy.Policies() {
+		if strings.HasPrefix(string(policy), "test-") {
+			continue // registered by the tests above
+		}
+		cache, err := cachePolicy.CreateCache(1000, cachePolicy.CacheCallBack{}, policy)
+		assert.NoError(t, err, policy)
+
+		_, ok := cache.Get("not_exist")
+		assert.False(t, ok, policy)
+
+		assert.NoError(t, cache.Add("key1", cachePolicy.Bytes("test1")), policy)
+		assert.Equal(t, 1, cache.Len(), policy)
+		size := cache.Size()
+		assert.GreaterOrEqual(t, size, int64(len("key1")+len("test1")), policy)
+
+		v, ok := cache.Get("key1")
+		assert.True(t, ok, policy)
+		assert.Equal(t, cachePolicy.Bytes("test1"), v, policy)
+
+		// policies may add a fixed cost per pair, but the value is counted as it is.
+		assert.NoError(t, cache.Add("key1", cachePolicy.Bytes("test22")), policy)
+		assert.Equal(t, 1, cache.Len(), policy)
+		assert.Equal(t, size+1, cache.Size(), policy)
+		v, ok = cache.Peek("key1")
+		assert.True(t, ok, policy)
+		assert.Equal(t, cachePolicy.Bytes("test22"), v, policy)
+
+		assert.True(t, cache.Remove("key1"), policy)
+		assert.False(t, cache.Remove("key1"), policy)
+		assert.False(t, cache.Contains("key1"), policy)
+		assert.Equal(t, 0, cache.Len(), policy)
+		assert.Equal(t, int64(0), cache.Size(), policy)
+
+		assert.Error(t, cache.Add("large", make(cachePolicy.Bytes, 1000)), policy)
+		cache.Evict() // no-op on an empty cache
+	}
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"fmt"
+	geecaches "geecache-s"
+	"geecache-s/cachePolicy"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ringRecordSize is the size of the record of a pair in a RingCache.
+func ringRecordSize(key string, value cachePolicy.Bytes) int64 {
+	return int64(25 + len(key) + len(value))
+}
+
+func TestRingRecordSize(t *testing.T) {
+	cache, err := cachePolicy.CreateCache(1000, cachePolicy.CacheCallBack{}, cachePolicy.RingPolicy)
+	assert.NoError(t, err)
+
+	assert.NoError(t, cache.Add("key1", cachePolicy.Bytes("test1")))
+	assert.Equal(t, ringRecordSize("key1", cachePolicy.Bytes("test1")), cache.Size())
+	assert.NoError(t, cache.Add("key1", cachePolicy.Bytes("test22")))
+	assert.Equal(t, ringRecordSize("key1", cachePolicy.Bytes("test22")), cache.Size())
+
+	assert.Error(t, cache.Add("string", String("needs a codec")))
+}
+
+func TestRingEviction(t *testing.T) {
+	evicted := make([]string, 0)
+	// every record takes 35 bytes, the cache holds 4 of them.
+	cache, err := cachePolicy.CreateCache(140, cachePolicy.CacheCallBack{
+		OnEvicted: func(key string, value cachePolicy.Value) {
+			evicted = append(evicted, key)
+		},
+	}, cachePolicy.RingPolicy)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"k1", "k2", "k3", "k4"} {
+		assert.NoError(t, cache.Add(key, make(cachePolicy.Bytes, 8)))
+	}
+	cache.Get("k1")
+
+	// k1 was read, it gets a second chance and k2 goes instead.
+	assert.NoError(t, cache.Add("k5", make(cachePolicy.Bytes, 8)))
+	assert.NoError(t, cache.Add("k6", make(cachePolicy.Bytes, 8)))
+	assert.Equal(t, []string{"k2", "k3"}, evicted)
+
+	for _, key := range []string{"k1", "k4", "k5", "k6"} {
+		assert.True(t, cache.Contains(key), "%s should be cached", key)
+	}
+	var keys []string
+	for key := range cache.All() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"k4", "k1", "k5", "k6"}, keys)
+}
+
+func TestRingExpireAndPurge(t *testing.T) {
+	var reasons []cachePolicy.EvictReason
+	cache := cachePolicy.NewRingCache(cachePolicy.Options{
+		CacheCallBack: cachePolicy.CacheCallBack{
+			OnEvictedWithReason: func(key string, value cachePolicy.Value, reason cachePolicy.EvictReason) {
+				reasons = append(reasons, reason)
+			},
+		},
+	})
+	assert.NoError(t, cache.AddWithExpire("a", cachePolicy.Bytes("1"), time.Now().Add(-time.Second)))
+	assert.NoError(t, cache.AddWithExpire("b", cachePolicy.Bytes("2"), time.Now().Add(-time.Second)))
+	assert.NoError(t, cache.AddWithExpire("c", cachePolicy.Bytes("3"), time.Now().Add(time.Hour)))
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.RemoveExpired())
+	assert.Equal(t, 1, cache.Len())
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, int64(0), cache.Size())
+	assert.Equal(t, []cachePolicy.EvictReason{cachePolicy.EvictExpired, cachePolicy.EvictExpired, cachePolicy.EvictRemoved}, reasons)
+
+	assert.NoError(t, cache.Add("d", cachePolicy.Bytes("4")))
+	v, ok := cache.Get("d")
+	assert.True(t, ok)
+	assert.Equal(t, cachePolicy.Bytes("4"), v)
+}
+
+func TestRingRandomOperations(t *testing.T) {
+	const maxBytes = 4 << 10
+	cache := cachePolicy.NewRingCache(cachePolicy.Options{MaxBytes: maxBytes})
+	latest := make(map[string]cachePolicy.Bytes)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		key := fmt.Sprintf("key%d", rnd.Intn(300))
+		switch rnd.Intn(10) {
+		case 0:
+			cache.Remove(key)
+			delete(latest, key)
+		case 1, 2, 3:
+			value := make(cachePolicy.Bytes, rnd.Intn(64))
+			rnd.Read(value)
+			assert.NoError(t, cache.Add(key, value))
+			latest[key] = value
+		case 4:
+			if i%1000 == 4 {
+				cache.Resize(int64(1<<10 + rnd.Intn(maxBytes)))
+			}
+		default:
+			if v, ok := cache.Get(key); ok {
+				assert.Equal(t, latest[key], v, key)
+			}
+		}
+		assert.LessOrEqual(t, cache.Size(), int64(maxBytes))
+	}
+
+	var size int64
+	n := 0
+	for key, value := range cache.All() {
+		assert.Equal(t, latest[key], value, key)
+		size += ringRecordSize(key, value.(cachePolicy.Bytes))
+		n++
+	}
+	assert.Equal(t, cache.Len(), n)
+	assert.Equal(t, cache.Size(), size)
+}
+
+func TestRingGroup(t *testing.T) {
+	opts := geecaches.NewGroupOptions()
+	opts.CachePolicy = cachePolicy.RingPolicy
+	opts.MaxBytes = 64 << 10
+	opts.Shards = 4
+	gee := geecaches.NewGroupWithOpts("ring", opts)
+
+	for i := 0; i < 1000; i++ {
+		assert.NoError(t, gee.AddWithTTL(fmt.Sprintf("key%d", i), geecaches.ByteView{Bytes: []byte(fmt.Sprint(i))}, time.Hour))
+	}
+	stats := gee.Stats().MainCache
+	assert.Greater(t, stats.Items, int64(0))
+	assert.LessOrEqual(t, stats.Bytes, opts.MaxBytes)
+
+	v, err := gee.Get("key999")
+	assert.NoError(t, err)
+	assert.Equal(t, "999", v.String())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), v.Expire(), time.Minute)
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"fmt"
+	"geecache-s/cachePolicy"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3FIFOOneHitWonders(t *testing.T) {
+	evicted := 0
+	// every entry takes 10 bytes, the cache holds 10 of them.
+	cache, err := cachePolicy.CreateCache(100, cachePolicy.CacheCallBack{
+		OnEvicted: func(key string, value cachePolicy.Value) { evicted++ },
+	}, cachePolicy.S3FifoPolicy)
+	assert.NoError(t, err)
+
+	// popular keys are accessed while they are in the small queue.
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+		cache.Get(key)
+		cache.Get(key)
+	}
+
+	// one-hit wonders leave through the small queue only.
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("one%03d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+		for j := 0; j < 5; j++ {
+			cache.Get(fmt.Sprintf("hot%d", j))
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		_, ok := cache.Get(fmt.Sprintf("hot%d", i))
+		assert.True(t, ok, "hot%d should not be evicted", i)
+	}
+	assert.LessOrEqual(t, cache.Size(), int64(100))
+	assert.Equal(t, 105-cache.Len(), evicted)
+
+	// a ghost added again goes to the main queue and survives the next one-hit wonders.
+	assert.NoError(t, cache.Add("one090", testValue{size: 4}))
+	for i := 100; i < 120; i++ {
+		key := fmt.Sprintf("one%03d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+	}
+	_, ok := cache.Get("one090")
+	assert.True(t, ok)
+}
@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"geecache-s/cachePolicy"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSIEVEEviction(t *testing.T) {
+	evicted := make([]string, 0)
+	// every entry takes 10 bytes, the cache holds 4 of them.
+	cache, err := cachePolicy.CreateCache(40, cachePolicy.CacheCallBack{
+		OnEvicted: func(key string, value cachePolicy.Value) {
+			evicted = append(evicted, key)
+		},
+	}, cachePolicy.SievePolicy)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"k1", "k2", "k3", "k4"} {
+		assert.NoError(t, cache.Add(key, testValue{size: 8}))
+	}
+	cache.Get("k1")
+	cache.Get("k3")
+
+	// the hand skips the visited k1 and evicts k2, then goes on from k3.
+	assert.NoError(t, cache.Add("k5", testValue{size: 8}))
+	assert.NoError(t, cache.Add("k6", testValue{size: 8}))
+	assert.Equal(t, []string{"k2", "k4"}, evicted)
+
+	for _, key := range []string{"k1", "k3", "k5", "k6"} {
+		_, ok := cache.Get(key)
+		assert.True(t, ok, "%s should be cached", key)
+	}
+}
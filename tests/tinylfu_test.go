@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"fmt"
+	"geecache-s/cachePolicy"
+	"geecache-s/cachePolicy/generic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTinyLFUAdmission(t *testing.T) {
+	evicted := 0
+	// every entry takes 10 bytes, the cache holds 10 of them.
+	cache, err := cachePolicy.CreateCache(100, cachePolicy.CacheCallBack{
+		OnEvicted: func(key string, value cachePolicy.Value) { evicted++ },
+	}, cachePolicy.TinyLfuPolicy)
+	assert.NoError(t, err)
+
+	// popular keys are read many times.
+	for i := 0; i < 9; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+		for j := 0; j < 5; j++ {
+			_, ok := cache.Get(key)
+			assert.True(t, ok)
+		}
+	}
+
+	// one-hit wonders are not worth evicting them.
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("one%03d", i)
+		assert.NoError(t, cache.Add(key, testValue{size: 10 - int64(len(key))}))
+	}
+
+	for i := 0; i < 9; i++ {
+		_, ok := cache.Get(fmt.Sprintf("hot%d", i))
+		assert.True(t, ok, "hot%d should not be evicted", i)
+	}
+	assert.LessOrEqual(t, cache.Size(), int64(100))
+	assert.Equal(t, 109-cache.Len(), evicted)
+}
+
+func TestTinyLFURejectKeepsVictims(t *testing.T) {
+	var evicted []string
+	cache := generic.NewTinyLFU(generic.Options[string, int]{
+		MaxCost:   100,
+		Cost:      func(key string, value int) int64 { return int64(value) },
+		OnEvicted: func(key string, value int) { evicted = append(evicted, key) },
+	})
+	assert.NoError(t, cache.Add("cold", 50))
+	assert.NoError(t, cache.Add("hot", 49))
+	for i := 0; i < 5; i++ {
+		cache.Get("hot")
+	}
+
+	// big is used more often than cold, but less than hot, which it would evict too.
+	cache.Get("big")
+	cache.Get("big")
+	assert.NoError(t, cache.Add("big", 60))
+	assert.Equal(t, []string{"big"}, evicted)
+	assert.True(t, cache.Contains("cold"))
+	assert.True(t, cache.Contains("hot"))
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	geecaches "geecache-s"
+	pb "geecache-s/geecachespb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	Name  string
+	Score int
+}
+
+func TestTypedGroup(t *testing.T) {
+	codecs := map[string]geecaches.Codec[user]{
+		"json": geecaches.JSONCodec[user]{},
+		"gob":  geecaches.GobCodec[user]{},
+	}
+	for name, codec := range codecs {
+		loads := 0
+		g := geecaches.NewTypedGroup("typed-"+name, codec,
+			func(ctx context.Context, key string) (user, error) {
+				loads++
+				if key == "unknown" {
+					return user{}, fmt.Errorf("%s not exist", key)
+				}
+				return user{Name: key, Score: len(key)}, nil
+			}, nil)
+
+		for i := 0; i < 2; i++ {
+			u, err := g.Get(context.Background(), "Tom")
+			assert.NoError(t, err, name)
+			assert.Equal(t, user{Name: "Tom", Score: 3}, u, name)
+		}
+		assert.Equal(t, 1, loads, name)
+
+		_, err := g.Get(context.Background(), "unknown")
+		assert.Error(t, err, name)
+
+		assert.NoError(t, g.Add("Sam", user{Name: "Sam", Score: 567}), name)
+		u, err := g.Get(context.Background(), "Sam")
+		assert.NoError(t, err, name)
+		assert.Equal(t, 567, u.Score, name)
+		assert.Equal(t, 2, loads, name)
+	}
+}
+
+func TestTypedGroupProto(t *testing.T) {
+	g := geecaches.NewTypedGroup("typed-proto", geecaches.ProtoCodec[*pb.GetRequest]{}, nil, nil)
+	assert.NoError(t, g.Add("req", &pb.GetRequest{Group: "scores", Key: "Tom"}))
+
+	req, err := g.Get(context.Background(), "req")
+	assert.NoError(t, err)
+	assert.Equal(t, "scores", req.GetGroup())
+	assert.Equal(t, "Tom", req.GetKey())
+}
+
+func TestTypedGroupDecodeError(t *testing.T) {
+	g := geecaches.NewTypedGroup[user]("typed-bad", geecaches.JSONCodec[user]{}, nil, nil)
+	assert.NoError(t, g.Group().Add("bad", geecaches.ByteView{Bytes: []byte("not json")}))
+
+	_, err := g.Get(context.Background(), "bad")
+	assert.ErrorContains(t, err, "decode")
+}
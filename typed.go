@@ -0,0 +1,82 @@
+package geecaches
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// A TypedGetterFunc loads the value of key when it is missing from the cache.
+type TypedGetterFunc[T any] func(ctx context.Context, key string) (T, error)
+
+// A TypedGroup is a Group of values of T, encoded by a Codec.
+// A value is decoded from the cached bytes on every Get and encoded once on every Add or load.
+type TypedGroup[T any] struct {
+	group *Group
+	codec Codec[T]
+}
+
+// NewTypedGroup creates a group whose values are encoded by codec, loaded by getter on a miss.
+// The Getter of opts is replaced by getter, opts may be nil to use the default options.
+// If a group with the same name exists, it is reused as is, like NewGroupWithOpts does.
+func NewTypedGroup[T any](name string, codec Codec[T], getter TypedGetterFunc[T], opts *GroupOptions) *TypedGroup[T] {
+	if opts == nil {
+		opts = NewGroupOptions()
+	}
+	groupOpts := *opts
+	if getter != nil {
+		groupOpts.Getter = ContextGetterFunc(func(ctx context.Context, key string) ([]byte, error) {
+			v, err := getter(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			return codec.Marshal(v)
+		})
+	}
+	return &TypedGroup[T]{
+		group: NewGroupWithOpts(name, &groupOpts),
+		codec: codec,
+	}
+}
+
+// Group returns the underlying group, e.g. to register peers.
+func (g *TypedGroup[T]) Group() *Group {
+	return g.group
+}
+
+func (g *TypedGroup[T]) Name() string {
+	return g.group.Name()
+}
+
+// Get returns the value of key, see Group.GetContext.
+func (g *TypedGroup[T]) Get(ctx context.Context, key string) (T, error) {
+	var v T
+	view, err := g.group.GetContext(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	// the view is immutable and the codec does not retain it, so there is no need to copy it.
+	if err := g.codec.Unmarshal(view.Bytes, &v); err != nil {
+		return v, fmt.Errorf("decode value of key %q in group %s: %w", key, g.group.Name(), err)
+	}
+	return v, nil
+}
+
+// Add stores value under key, see Group.Add.
+func (g *TypedGroup[T]) Add(key string, value T) error {
+	return g.AddWithTTL(key, value, g.group.defaultTTL)
+}
+
+// AddWithTTL is like Add, but the entry expires after ttl.
+func (g *TypedGroup[T]) AddWithTTL(key string, value T, ttl time.Duration) error {
+	data, err := g.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode value of key %q in group %s: %w", key, g.group.Name(), err)
+	}
+	return g.group.AddWithTTL(key, ByteView{Bytes: data}, ttl)
+}
+
+// Remove evicts key, see Group.Remove.
+func (g *TypedGroup[T]) Remove(key string) error {
+	return g.group.Remove(key)
+}